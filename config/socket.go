@@ -1,21 +1,341 @@
 package config
 
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/orchestra-mcp/socket/src/bridge"
+)
+
 // SocketConfig holds WebSocket server configuration.
 type SocketConfig struct {
-	MaxConnections  int `json:"max_connections"`
-	PingInterval    int `json:"ping_interval_seconds"`
-	WriteTimeout    int `json:"write_timeout_seconds"`
-	ReadBufferSize  int `json:"read_buffer_size"`
-	WriteBufferSize int `json:"write_buffer_size"`
+	MaxConnections        int `json:"max_connections"`
+	MaxConnectionsPerIP   int `json:"max_connections_per_ip"`
+	MaxConnectionsPerUser int `json:"max_connections_per_user"`
+	RetryAfter            int `json:"retry_after_seconds"`
+	PingInterval          int `json:"ping_interval_seconds"`
+	WriteTimeout          int `json:"write_timeout_seconds"`
+	ReadBufferSize        int `json:"read_buffer_size"`
+	WriteBufferSize       int `json:"write_buffer_size"`
+	SendBufferSize        int `json:"send_buffer_size"`
+	HubQueueSize          int `json:"hub_queue_size"`
+	HubShards             int `json:"hub_shards"` // event-loop shards, 0 = GOMAXPROCS
+	HandlerWorkers        int `json:"handler_workers"`
+	HandlerQueueSize      int `json:"handler_queue_size"`
+	HandlerTimeout        int `json:"handler_timeout_seconds"` // 0 disables
+	RPCTimeout            int `json:"rpc_timeout_seconds"`     // 0 disables
+	ResumeGrace           int `json:"resume_grace_seconds"`
+	ReplayBufferSize      int `json:"replay_buffer_size"`
+
+	SlowConsumer SlowConsumerConfig       `json:"slow_consumer"`
+	History      map[string]HistoryConfig `json:"history"` // channel pattern -> retention
+	Bridge       BridgeConfig             `json:"bridge"`
+}
+
+// HistoryConfig bounds the messages retained for matching channels.
+type HistoryConfig struct {
+	Limit         int `json:"limit"`
+	MaxAgeSeconds int `json:"max_age_seconds"`
+}
+
+// SlowConsumerConfig selects how the hub treats clients whose send buffer
+// is full: "drop_newest", "drop_oldest", "disconnect" or "block".
+type SlowConsumerConfig struct {
+	Policy    string `json:"policy"`
+	TimeoutMs int    `json:"timeout_ms"` // wait before dropping with "block"
+	CloseCode int    `json:"close_code"` // close frame code with "disconnect"
+}
+
+// BridgeConfig holds settings for the cross-instance bridge: Driver picks
+// "redis" (the default, configured by the top-level fields), "nats" (the
+// nats section) or "postgres" (the postgres section).
+type BridgeConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Driver   string `json:"driver"` // "redis", "nats" or "postgres"
+	Addr     string `json:"addr"`
+	Username string `json:"username"` // Redis ACL user
+	Password string `json:"password"`
+	DB       int    `json:"db"`
+	Prefix   string `json:"prefix"`
+
+	Addrs            []string       `json:"addrs"`       // Sentinel or Cluster seed nodes
+	MasterName       string         `json:"master_name"` // Sentinel master; enables Sentinel
+	SentinelUsername string         `json:"sentinel_username"`
+	SentinelPassword string         `json:"sentinel_password"`
+	Cluster          bool           `json:"cluster"` // Redis Cluster with sharded pub/sub
+	TLS              RedisTLSConfig `json:"tls"`
+	Streams          StreamsConfig  `json:"streams"`
+
+	ReconnectMinMs  int `json:"reconnect_min_ms"`  // first retry delay
+	ReconnectMaxMs  int `json:"reconnect_max_ms"`  // backoff cap
+	HealthCheckMs   int `json:"health_check_ms"`   // ping interval while connected
+	BufferSize      int `json:"buffer_size"`       // publishes held during an outage, 0 disables
+	StatsIntervalMs int `json:"stats_interval_ms"` // node stats heartbeat for cluster-wide counts
+
+	NodeAddress string `json:"node_address"` // address advertised in the node registry, default hostname
+
+	Nats     NatsConfig     `json:"nats"`
+	Postgres PostgresConfig `json:"postgres"`
+}
+
+// overlayRedisEnv copies the Redis settings bridge.RedisConfigFromEnv
+// reads from REDIS_* variables; unset variables leave the defaults.
+func (c *BridgeConfig) overlayRedisEnv() {
+	env := bridge.RedisConfigFromEnv()
+	c.Addr = env.Addr
+	c.Username = env.Username
+	c.Password = env.Password
+	c.DB = env.DB
+	c.Prefix = env.Prefix
+	c.Addrs = env.Addrs
+	c.MasterName = env.MasterName
+	c.SentinelUsername = env.SentinelUsername
+	c.SentinelPassword = env.SentinelPassword
+	c.Cluster = env.Cluster
+	c.TLS.Enabled = env.TLS
+	c.TLS.CAFile = env.TLSCAFile
+	c.TLS.CertFile = env.TLSCertFile
+	c.TLS.KeyFile = env.TLSKeyFile
+	c.TLS.ServerName = env.TLSServerName
+	c.Streams.Enabled = env.Streams
+	c.Streams.Consumer = env.StreamConsumer
+	c.NodeAddress = env.NodeAddress
+}
+
+// RedisTLSConfig holds TLS settings for the Redis bridge driver.
+type RedisTLSConfig struct {
+	Enabled            bool   `json:"enabled"`
+	CAFile             string `json:"ca_file"`   // PEM CA bundle, default system roots
+	CertFile           string `json:"cert_file"` // client certificate for mutual TLS
+	KeyFile            string `json:"key_file"`
+	ServerName         string `json:"server_name"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+}
+
+// StreamsConfig switches the Redis bridge driver to Redis Streams.
+type StreamsConfig struct {
+	Enabled       bool   `json:"enabled"`
+	MaxLen        int    `json:"max_len"`         // approximate entries kept, 0 disables
+	MaxAgeSeconds int    `json:"max_age_seconds"` // entries older than this are trimmed, 0 disables
+	Consumer      string `json:"consumer"`        // names this instance's read position, unique per instance; default the instance ID
+}
+
+// NatsConfig holds settings for the NATS bridge driver.
+type NatsConfig struct {
+	URL                 string `json:"url"`
+	Subject             string `json:"subject"` // subject prefix
+	JetStream           bool   `json:"jetstream"`
+	Stream              string `json:"stream"`
+	StreamMaxAgeSeconds int    `json:"stream_max_age_seconds"`
+	RequestTimeoutMs    int    `json:"request_timeout_ms"` // direct-message ack wait
+}
+
+// PostgresConfig holds settings for the Postgres LISTEN/NOTIFY bridge driver.
+type PostgresConfig struct {
+	DSN             string `json:"dsn"`
+	Channel         string `json:"channel"` // NOTIFY channel
+	Table           string `json:"table"`   // spill table for oversized payloads
+	SpillTTLSeconds int    `json:"spill_ttl_seconds"`
 }
 
 // DefaultConfig returns the default WebSocket configuration.
 func DefaultConfig() *SocketConfig {
 	return &SocketConfig{
-		MaxConnections:  1000,
-		PingInterval:    30,
-		WriteTimeout:    10,
-		ReadBufferSize:  1024,
-		WriteBufferSize: 1024,
+		MaxConnections:   1000,
+		RetryAfter:       5,
+		PingInterval:     30,
+		WriteTimeout:     10,
+		ReadBufferSize:   1024,
+		WriteBufferSize:  1024,
+		SendBufferSize:   256,
+		HubQueueSize:     256,
+		HandlerWorkers:   32,
+		HandlerQueueSize: 1024,
+		HandlerTimeout:   30,
+		RPCTimeout:       10,
+		ResumeGrace:      60,
+		ReplayBufferSize: 100,
+		SlowConsumer: SlowConsumerConfig{
+			Policy:    "drop_newest",
+			TimeoutMs: 100,
+			CloseCode: 1008,
+		},
+		Bridge: BridgeConfig{
+			Enabled:         true,
+			Driver:          "redis",
+			Addr:            "localhost:6379",
+			Prefix:          "orchestra:ws:",
+			ReconnectMinMs:  500,
+			ReconnectMaxMs:  30000,
+			HealthCheckMs:   1000,
+			StatsIntervalMs: 5000,
+			Streams: StreamsConfig{
+				MaxLen:        10000,
+				MaxAgeSeconds: 3600,
+			},
+			Nats: NatsConfig{
+				URL:                 "nats://127.0.0.1:4222",
+				Subject:             "orchestra.ws",
+				Stream:              "ORCHESTRA_WS",
+				StreamMaxAgeSeconds: 3600,
+				RequestTimeoutMs:    2000,
+			},
+			Postgres: PostgresConfig{
+				Channel:         "orchestra_ws",
+				Table:           "orchestra_ws_spill",
+				SpillTTLSeconds: 300,
+			},
+		},
+	}
+}
+
+// FromMap overlays the REDIS_* environment variables and then raw plugin
+// configuration onto the defaults and validates the result. Keys must
+// match the JSON tags of SocketConfig; unknown keys are rejected so typos
+// do not silently fall back to defaults.
+func FromMap(raw map[string]any) (*SocketConfig, error) {
+	cfg := DefaultConfig()
+	cfg.Bridge.overlayRedisEnv()
+	if len(raw) == 0 {
+		return cfg, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("socket config: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("socket config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ToMap returns the configuration keyed by its JSON tags.
+func (c *SocketConfig) ToMap() map[string]any {
+	data, _ := json.Marshal(c)
+	var m map[string]any
+	_ = json.Unmarshal(data, &m)
+	return m
+}
+
+// Validate reports every invalid field.
+func (c *SocketConfig) Validate() error {
+	var errs []error
+	nonNegative := func(key string, v int) {
+		if v < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", key))
+		}
+	}
+	positive := func(key string, v int) {
+		if v <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", key))
+		}
+	}
+
+	nonNegative("max_connections", c.MaxConnections)
+	nonNegative("max_connections_per_ip", c.MaxConnectionsPerIP)
+	nonNegative("max_connections_per_user", c.MaxConnectionsPerUser)
+	nonNegative("retry_after_seconds", c.RetryAfter)
+	nonNegative("ping_interval_seconds", c.PingInterval)
+	nonNegative("write_timeout_seconds", c.WriteTimeout)
+	positive("read_buffer_size", c.ReadBufferSize)
+	positive("write_buffer_size", c.WriteBufferSize)
+	positive("send_buffer_size", c.SendBufferSize)
+	positive("hub_queue_size", c.HubQueueSize)
+	nonNegative("hub_shards", c.HubShards)
+	positive("handler_workers", c.HandlerWorkers)
+	positive("handler_queue_size", c.HandlerQueueSize)
+	nonNegative("handler_timeout_seconds", c.HandlerTimeout)
+	nonNegative("rpc_timeout_seconds", c.RPCTimeout)
+	nonNegative("resume_grace_seconds", c.ResumeGrace)
+	nonNegative("replay_buffer_size", c.ReplayBufferSize)
+
+	switch c.SlowConsumer.Policy {
+	case "drop_newest", "drop_oldest", "disconnect", "block":
+	default:
+		errs = append(errs, fmt.Errorf("slow_consumer.policy %q is not one of drop_newest, drop_oldest, disconnect, block", c.SlowConsumer.Policy))
+	}
+	nonNegative("slow_consumer.timeout_ms", c.SlowConsumer.TimeoutMs)
+	nonNegative("slow_consumer.close_code", c.SlowConsumer.CloseCode)
+
+	for pattern, hc := range c.History {
+		nonNegative("history."+pattern+".limit", hc.Limit)
+		nonNegative("history."+pattern+".max_age_seconds", hc.MaxAgeSeconds)
+		if hc.Limit == 0 && hc.MaxAgeSeconds == 0 {
+			errs = append(errs, fmt.Errorf("history.%s needs a limit or max_age_seconds", pattern))
+		}
+	}
+
+	if c.Bridge.Enabled {
+		positive("bridge.reconnect_min_ms", c.Bridge.ReconnectMinMs)
+		if c.Bridge.ReconnectMaxMs < c.Bridge.ReconnectMinMs {
+			errs = append(errs, errors.New("bridge.reconnect_max_ms must not be less than bridge.reconnect_min_ms"))
+		}
+		switch c.Bridge.Driver {
+		case "redis":
+			nonNegative("bridge.db", c.Bridge.DB)
+			positive("bridge.health_check_ms", c.Bridge.HealthCheckMs)
+			nonNegative("bridge.buffer_size", c.Bridge.BufferSize)
+			positive("bridge.stats_interval_ms", c.Bridge.StatsIntervalMs)
+			if c.Bridge.Addr == "" && len(c.Bridge.Addrs) == 0 {
+				errs = append(errs, errors.New("bridge.addr is required when the bridge is enabled"))
+			}
+			if c.Bridge.Cluster && c.Bridge.MasterName != "" {
+				errs = append(errs, errors.New("bridge.cluster and bridge.master_name are mutually exclusive"))
+			}
+			if c.Bridge.Cluster && c.Bridge.DB != 0 {
+				errs = append(errs, errors.New("bridge.db must be 0 with bridge.cluster"))
+			}
+			if c.Bridge.MasterName != "" && len(c.Bridge.Addrs) == 0 {
+				errs = append(errs, errors.New("bridge.addrs must list the sentinels when bridge.master_name is set"))
+			}
+			if tc := c.Bridge.TLS; (tc.CertFile == "") != (tc.KeyFile == "") {
+				errs = append(errs, errors.New("bridge.tls.cert_file and bridge.tls.key_file must be set together"))
+			}
+			nonNegative("bridge.streams.max_len", c.Bridge.Streams.MaxLen)
+			nonNegative("bridge.streams.max_age_seconds", c.Bridge.Streams.MaxAgeSeconds)
+			if c.Bridge.Prefix == "" {
+				errs = append(errs, errors.New("bridge.prefix is required when the bridge is enabled"))
+			}
+		case "nats":
+			nc := c.Bridge.Nats
+			if nc.URL == "" {
+				errs = append(errs, errors.New("bridge.nats.url is required with the nats driver"))
+			}
+			if nc.Subject == "" || strings.ContainsAny(nc.Subject, "*> \t") {
+				errs = append(errs, fmt.Errorf("bridge.nats.subject %q is not a valid subject prefix", nc.Subject))
+			}
+			if nc.JetStream && nc.Stream == "" {
+				errs = append(errs, errors.New("bridge.nats.stream is required with jetstream"))
+			}
+			nonNegative("bridge.nats.stream_max_age_seconds", nc.StreamMaxAgeSeconds)
+			positive("bridge.nats.request_timeout_ms", nc.RequestTimeoutMs)
+		case "postgres":
+			pc := c.Bridge.Postgres
+			if pc.DSN == "" {
+				errs = append(errs, errors.New("bridge.postgres.dsn is required with the postgres driver"))
+			}
+			if pc.Channel == "" || len(pc.Channel) > 63 {
+				errs = append(errs, fmt.Errorf("bridge.postgres.channel %q must be 1 to 63 bytes", pc.Channel))
+			}
+			if pc.Table == "" {
+				errs = append(errs, errors.New("bridge.postgres.table is required with the postgres driver"))
+			}
+			positive("bridge.postgres.spill_ttl_seconds", pc.SpillTTLSeconds)
+		default:
+			errs = append(errs, fmt.Errorf("bridge.driver %q is not one of redis, nats, postgres", c.Bridge.Driver))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("socket config: %w", errors.Join(errs...))
 	}
+	return nil
 }
@@ -1,6 +1,11 @@
 package providers
 
-import "github.com/orchestra-mcp/framework/app/plugins"
+import (
+	"github.com/orchestra-mcp/framework/app/plugins"
+	"github.com/orchestra-mcp/socket/src/bridge"
+	"github.com/orchestra-mcp/socket/src/hub"
+	"github.com/orchestra-mcp/socket/src/types"
+)
 
 // Compile-time interface assertions.
 var (
@@ -10,4 +15,29 @@ var (
 	_ plugins.HasRoutes      = (*SocketPlugin)(nil)
 	_ plugins.HasMcpTools    = (*SocketPlugin)(nil)
 	_ plugins.HasServices    = (*SocketPlugin)(nil)
+
+	_ types.HeartbeatConn = (*fasthttpConn)(nil)
+	_ types.CloseCoder    = (*fasthttpConn)(nil)
+	_ types.FrameConn     = (*fasthttpConn)(nil)
+
+	_ hub.ChannelBridge = (*bridge.RedisBridge)(nil)
+	_ hub.DirectBridge  = (*bridge.RedisBridge)(nil)
+	_ hub.ClusterBridge = (*bridge.RedisBridge)(nil)
+	_ hub.ChannelBridge = (*bridge.NatsBridge)(nil)
+	_ hub.DirectBridge  = (*bridge.NatsBridge)(nil)
+	_ hub.ChannelBridge = (*bridge.MemoryBridge)(nil)
+	_ hub.DirectBridge  = (*bridge.MemoryBridge)(nil)
+	_ hub.ClusterBridge = (*bridge.MemoryBridge)(nil)
+
+	_ hub.DirectoryBridge = (*bridge.RedisBridge)(nil)
+	_ hub.NodeMonitor     = (*bridge.RedisBridge)(nil)
+	_ hub.PresenceBridge  = (*bridge.RedisBridge)(nil)
+	_ hub.DirectoryBridge = (*bridge.MemoryBridge)(nil)
+
+	_ bridge.KickTarget = (*hub.Hub)(nil)
+
+	_ bridge.StateNotifier = (*bridge.RedisBridge)(nil)
+	_ bridge.StateNotifier = (*bridge.NatsBridge)(nil)
+	_ bridge.StateNotifier = (*bridge.PostgresBridge)(nil)
+	_ bridge.StateNotifier = (*bridge.MemoryBridge)(nil)
 )
@@ -1,8 +1,13 @@
 package providers
 
 import (
+	"strings"
+	"time"
+
+	"github.com/fasthttp/websocket"
 	"github.com/orchestra-mcp/framework/app/plugins"
 	"github.com/orchestra-mcp/socket/config"
+	"github.com/orchestra-mcp/socket/src/auth"
 	"github.com/orchestra-mcp/socket/src/bridge"
 	"github.com/orchestra-mcp/socket/src/hub"
 	"github.com/orchestra-mcp/socket/src/service"
@@ -10,17 +15,23 @@ import (
 
 // SocketPlugin implements the Orchestra plugin interface for WebSocket.
 type SocketPlugin struct {
-	active  bool
-	ctx     *plugins.PluginContext
-	cfg     *config.SocketConfig
-	hub     *hub.Hub
-	service *service.Service
-	bridge  bridge.Bridge
+	active   bool
+	ctx      *plugins.PluginContext
+	cfg      *config.SocketConfig
+	hub      *hub.Hub
+	service  *service.Service
+	bridge   bridge.Bridge
+	authn    auth.Authenticator
+	upgrader websocket.FastHTTPUpgrader
 }
 
 // NewSocketPlugin creates a new WebSocket plugin instance.
 func NewSocketPlugin() *SocketPlugin { return &SocketPlugin{} }
 
+// SetAuthenticator installs the authenticator run before every WebSocket
+// upgrade. Without one, all connections are accepted anonymously.
+func (p *SocketPlugin) SetAuthenticator(a auth.Authenticator) { p.authn = a }
+
 func (p *SocketPlugin) ID() string             { return "orchestra/socket" }
 func (p *SocketPlugin) Name() string           { return "WebSocket" }
 func (p *SocketPlugin) Version() string        { return "0.1.0" }
@@ -29,21 +40,67 @@ func (p *SocketPlugin) IsActive() bool         { return p.active }
 func (p *SocketPlugin) FeatureFlag() string    { return "websocket" }
 func (p *SocketPlugin) ConfigKey() string      { return "socket" }
 
+// DefaultConfig returns the defaults keyed exactly as Activate parses them,
+// with the REDIS_* environment variables applied, so that a framework
+// seeding the plugin config from it keeps their values.
 func (p *SocketPlugin) DefaultConfig() map[string]any {
-	return map[string]any{
-		"max_connections": 1000,
-		"ping_interval":   30,
-		"write_timeout":   10,
-		"read_buffer":     1024,
-		"write_buffer":    1024,
+	cfg, err := config.FromMap(nil)
+	if err != nil {
+		cfg = config.DefaultConfig()
 	}
+	return cfg.ToMap()
 }
 
-// Activate initializes the hub, service, and starts the event loop.
+// Activate parses the plugin configuration, initializes the hub, service,
+// and upgrader, and starts the event loop.
 func (p *SocketPlugin) Activate(ctx *plugins.PluginContext) error {
+	cfg, err := config.FromMap(ctx.Config)
+	if err != nil {
+		return err
+	}
+
 	p.ctx = ctx
-	p.cfg = config.DefaultConfig()
-	p.hub = hub.New(ctx.Logger)
+	p.cfg = cfg
+	p.upgrader = websocket.FastHTTPUpgrader{
+		ReadBufferSize:  cfg.ReadBufferSize,
+		WriteBufferSize: cfg.WriteBufferSize,
+	}
+	p.hub = hub.New(ctx.Logger,
+		hub.WithQueueSize(cfg.HubQueueSize),
+		hub.WithShards(cfg.HubShards),
+		hub.WithSendBuffer(cfg.SendBufferSize),
+		hub.WithHandlerWorkers(cfg.HandlerWorkers),
+		hub.WithHandlerQueue(cfg.HandlerQueueSize),
+		hub.WithHandlerTimeout(time.Duration(cfg.HandlerTimeout)*time.Second),
+		hub.WithRPCTimeout(time.Duration(cfg.RPCTimeout)*time.Second),
+	)
+	p.hub.SetHeartbeat(
+		time.Duration(p.cfg.PingInterval)*time.Second,
+		time.Duration(p.cfg.WriteTimeout)*time.Second,
+	)
+	p.hub.SetLimits(hub.Limits{
+		MaxConnections: p.cfg.MaxConnections,
+		MaxPerIP:       p.cfg.MaxConnectionsPerIP,
+		MaxPerUser:     p.cfg.MaxConnectionsPerUser,
+	})
+	p.hub.SetResume(
+		time.Duration(cfg.ResumeGrace)*time.Second,
+		cfg.ReplayBufferSize,
+	)
+	for pattern, hc := range cfg.History {
+		err := p.hub.SetChannelHistory(pattern, hub.HistoryPolicy{
+			Limit:  hc.Limit,
+			MaxAge: time.Duration(hc.MaxAgeSeconds) * time.Second,
+		})
+		if err != nil {
+			ctx.Logger.Warn().Err(err).Str("pattern", pattern).Msg("channel history not retained")
+		}
+	}
+	p.hub.SetSlowConsumerPolicy(hub.SlowConsumerPolicy{
+		Overflow:  hub.OverflowPolicy(cfg.SlowConsumer.Policy),
+		Timeout:   time.Duration(cfg.SlowConsumer.TimeoutMs) * time.Millisecond,
+		CloseCode: cfg.SlowConsumer.CloseCode,
+	})
 	p.service = service.New(p.hub, ctx.Logger)
 
 	go p.hub.Run()
@@ -56,20 +113,88 @@ func (p *SocketPlugin) Activate(ctx *plugins.PluginContext) error {
 	return nil
 }
 
-// initBridge tries to start the Redis pub/sub bridge.
-// If Redis is not reachable, the hub runs in standalone mode.
+// initBridge starts the configured cross-instance bridge. Bridges connect
+// in the background and reconnect on their own, so the hub runs standalone
+// only while the backend is unreachable or when the bridge is disabled.
 func (p *SocketPlugin) initBridge(ctx *plugins.PluginContext) {
-	cfg := bridge.RedisConfigFromEnv()
-	rb := bridge.NewRedisBridge(cfg, p.hub, ctx.Logger)
+	if !p.cfg.Bridge.Enabled {
+		return
+	}
+	bc := p.cfg.Bridge
+
+	var b interface {
+		bridge.Bridge
+		bridge.StateNotifier
+	}
+	var target string
+	switch bc.Driver {
+	case "nats":
+		cfg := &bridge.NatsConfig{
+			URL:            bc.Nats.URL,
+			Subject:        bc.Nats.Subject,
+			Name:           "orchestra-socket",
+			JetStream:      bc.Nats.JetStream,
+			Stream:         bc.Nats.Stream,
+			MaxAge:         time.Duration(bc.Nats.StreamMaxAgeSeconds) * time.Second,
+			RequestTimeout: time.Duration(bc.Nats.RequestTimeoutMs) * time.Millisecond,
+		}
+		b, target = bridge.NewNatsBridge(cfg, p.hub, ctx.Logger), cfg.URL
+	case "postgres":
+		cfg := &bridge.PostgresConfig{
+			DSN:          bc.Postgres.DSN,
+			Channel:      bc.Postgres.Channel,
+			Table:        bc.Postgres.Table,
+			ReconnectMin: time.Duration(bc.ReconnectMinMs) * time.Millisecond,
+			ReconnectMax: time.Duration(bc.ReconnectMaxMs) * time.Millisecond,
+			SpillTTL:     time.Duration(bc.Postgres.SpillTTLSeconds) * time.Second,
+		}
+		b, target = bridge.NewPostgresBridge(cfg, p.hub, ctx.Logger), cfg.Channel
+	default:
+		cfg := &bridge.RedisConfig{
+			Addr:                  bc.Addr,
+			Username:              bc.Username,
+			Password:              bc.Password,
+			DB:                    bc.DB,
+			Prefix:                bc.Prefix,
+			Addrs:                 bc.Addrs,
+			MasterName:            bc.MasterName,
+			SentinelUsername:      bc.SentinelUsername,
+			SentinelPassword:      bc.SentinelPassword,
+			Cluster:               bc.Cluster,
+			TLS:                   bc.TLS.Enabled,
+			TLSCAFile:             bc.TLS.CAFile,
+			TLSCertFile:           bc.TLS.CertFile,
+			TLSKeyFile:            bc.TLS.KeyFile,
+			TLSServerName:         bc.TLS.ServerName,
+			TLSInsecureSkipVerify: bc.TLS.InsecureSkipVerify,
+			ReconnectMin:          time.Duration(bc.ReconnectMinMs) * time.Millisecond,
+			ReconnectMax:          time.Duration(bc.ReconnectMaxMs) * time.Millisecond,
+			HealthInterval:        time.Duration(bc.HealthCheckMs) * time.Millisecond,
+			BufferSize:            bc.BufferSize,
+			StatsInterval:         time.Duration(bc.StatsIntervalMs) * time.Millisecond,
+			Streams:               bc.Streams.Enabled,
+			StreamMaxLen:          int64(bc.Streams.MaxLen),
+			StreamMaxAge:          time.Duration(bc.Streams.MaxAgeSeconds) * time.Second,
+			StreamConsumer:        bc.Streams.Consumer,
+			NodeAddress:           bc.NodeAddress,
+		}
+		target = cfg.Addr
+		if len(cfg.Addrs) > 0 {
+			target = strings.Join(cfg.Addrs, ",")
+		}
+		b = bridge.NewRedisBridge(cfg, p.hub, ctx.Logger)
+	}
+	b.OnStateChange(func(s bridge.State) {
+		ctx.Logger.Info().Str("driver", bc.Driver).Str("target", target).Str("state", string(s)).Msg("bridge state")
+	})
 
-	if err := rb.Start(); err != nil {
-		ctx.Logger.Warn().Err(err).Msg("redis bridge unavailable, running standalone")
+	if err := b.Start(); err != nil {
+		ctx.Logger.Warn().Err(err).Str("driver", bc.Driver).Msg("bridge failed to start, running standalone")
 		return
 	}
 
-	p.bridge = rb
-	p.hub.SetBridge(rb)
-	ctx.Logger.Info().Str("redis_addr", cfg.Addr).Msg("redis bridge connected")
+	p.bridge = b
+	p.hub.SetBridge(b)
 }
 
 // Deactivate stops the bridge and hub event loop.
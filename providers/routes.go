@@ -1,20 +1,22 @@
 package providers
 
 import (
+	"encoding/json"
+	"errors"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fasthttp/websocket"
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
+	"github.com/orchestra-mcp/socket/src/auth"
+	"github.com/orchestra-mcp/socket/src/bridge"
 	"github.com/orchestra-mcp/socket/src/hub"
+	"github.com/orchestra-mcp/socket/src/types"
 	"github.com/valyala/fasthttp"
 )
 
-var upgrader = websocket.FastHTTPUpgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-}
-
 // RegisterRoutes registers the WebSocket info route via Fiber.
 // The actual WebSocket upgrade uses FastHTTPHandler, registered
 // at the app level since Fiber v3 does not expose *fasthttp.RequestCtx.
@@ -23,14 +25,50 @@ func (p *SocketPlugin) RegisterRoutes(group fiber.Router) {
 }
 
 func (p *SocketPlugin) handleInfo(c fiber.Ctx) error {
+	if c.Query("scope") == "cluster" {
+		return p.handleClusterInfo(c)
+	}
+	stats := p.hub.Stats()
 	return c.JSON(fiber.Map{
 		"websocket": true,
 		"endpoint":  "/ws",
-		"clients":   p.hub.ClientCount(),
-		"channels":  len(p.hub.Channels()),
+		"clients":   stats.Clients,
+		"channels":  stats.Channels,
+		"rejected":  stats.Rejected,
+		"handlers":  stats.Handlers,
+		"bridge":    p.bridgeState(),
+	})
+}
+
+// handleClusterInfo reports counts aggregated across all instances.
+func (p *SocketPlugin) handleClusterInfo(c fiber.Ctx) error {
+	stats, err := p.hub.ClusterStats()
+	if err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   "cluster_unavailable",
+			"message": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"websocket":   true,
+		"endpoint":    "/ws",
+		"scope":       "cluster",
+		"clients":     stats.Clients,
+		"channels":    len(stats.Channels),
+		"subscribers": stats.Channels,
+		"nodes":       stats.Nodes,
+		"bridge":      p.bridgeState(),
 	})
 }
 
+// bridgeState reports the bridge connection state, or "disabled".
+func (p *SocketPlugin) bridgeState() string {
+	if sn, ok := p.bridge.(bridge.StateNotifier); ok {
+		return string(sn.State())
+	}
+	return "disabled"
+}
+
 // FastHTTPHandler returns a raw fasthttp handler for WebSocket upgrades.
 // Register this on the fasthttp server at the "/ws" path.
 func (p *SocketPlugin) FastHTTPHandler() fasthttp.RequestHandler {
@@ -42,22 +80,105 @@ func (p *SocketPlugin) FastHTTPHandler() fasthttp.RequestHandler {
 			return
 		}
 
-		clientID := uuid.New().String()
+		var identity types.Identity
+		if p.authn != nil {
+			id, err := p.authn.Authenticate(&fasthttpRequest{ctx})
+			if err != nil {
+				rejectAuth(ctx, err)
+				return
+			}
+			if id != nil {
+				identity = *id
+			}
+		}
+
 		h := p.hub
+		ip := ctx.RemoteIP().String()
+		if err := h.Admit(ip, identity.UserID); err != nil {
+			rejectLimit(ctx, err, p.cfg.RetryAfter)
+			return
+		}
+
+		token := string(ctx.QueryArgs().Peek("resume"))
+		userAgent := string(ctx.UserAgent())
 		logger := p.ctx.Logger
 
-		err := upgrader.Upgrade(ctx, func(conn *websocket.Conn) {
+		err := p.upgrader.Upgrade(ctx, func(conn *websocket.Conn) {
+			defer h.Release(ip, identity.UserID)
+			// Claim only once upgraded: a claim cannot be undone, and a
+			// failed upgrade must leave the session resumable.
+			clientID := uuid.New().String()
+			if token != "" {
+				if id, ok := h.ClaimSession(token, identity.UserID); ok {
+					clientID = id
+				}
+			}
 			client := hub.NewClient(clientID, &fasthttpConn{conn}, h)
+			client.SetIdentity(identity)
+			client.SetUserAgent(userAgent)
 			h.Register(client)
 			go client.WritePump()
 			client.ReadPump()
 		})
 		if err != nil {
+			h.Release(ip, identity.UserID)
 			logger.Error().Err(err).Msg("websocket upgrade failed")
 		}
 	}
 }
 
+// rejectAuth writes a 401 or 403 response for a failed authentication.
+func rejectAuth(ctx *fasthttp.RequestCtx, err error) {
+	status, code := fasthttp.StatusUnauthorized, "unauthorized"
+	if errors.Is(err, auth.ErrForbidden) {
+		status, code = fasthttp.StatusForbidden, "forbidden"
+	}
+	writeError(ctx, status, code, err.Error())
+}
+
+// rejectLimit writes a 503 (server full) or 429 (per-IP or per-user cap)
+// response with a Retry-After header.
+func rejectLimit(ctx *fasthttp.RequestCtx, err error, retryAfter int) {
+	status := fasthttp.StatusServiceUnavailable
+	var le *hub.LimitError
+	if errors.As(err, &le) && le.Scope != hub.LimitGlobal {
+		status = fasthttp.StatusTooManyRequests
+	}
+	if retryAfter > 0 {
+		ctx.Response.Header.Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+	writeError(ctx, status, "too_many_connections", err.Error())
+}
+
+// writeError writes a JSON error body in the same shape as upgrade_required.
+func writeError(ctx *fasthttp.RequestCtx, status int, code, message string) {
+	body, _ := json.Marshal(map[string]string{"error": code, "message": message})
+	ctx.SetStatusCode(status)
+	ctx.SetContentType("application/json")
+	ctx.SetBody(body)
+}
+
+// fasthttpRequest adapts *fasthttp.RequestCtx to auth.Request.
+type fasthttpRequest struct {
+	ctx *fasthttp.RequestCtx
+}
+
+func (r *fasthttpRequest) Header(key string) string {
+	return string(r.ctx.Request.Header.Peek(key))
+}
+
+func (r *fasthttpRequest) Cookie(name string) string {
+	return string(r.ctx.Request.Header.Cookie(name))
+}
+
+func (r *fasthttpRequest) Query(key string) string {
+	return string(r.ctx.QueryArgs().Peek(key))
+}
+
+func (r *fasthttpRequest) RemoteAddr() string {
+	return r.ctx.RemoteIP().String()
+}
+
 // fasthttpConn wraps fasthttp/websocket.Conn to satisfy types.Conn.
 type fasthttpConn struct {
 	conn *websocket.Conn
@@ -66,3 +187,33 @@ type fasthttpConn struct {
 func (f *fasthttpConn) WriteJSON(v any) error { return f.conn.WriteJSON(v) }
 func (f *fasthttpConn) ReadJSON(v any) error  { return f.conn.ReadJSON(v) }
 func (f *fasthttpConn) Close() error          { return f.conn.Close() }
+
+// WriteFrame writes a broadcast frame through a websocket.PreparedMessage
+// shared by every connection the frame goes to, so its wire encoding is
+// also built once per frame.
+func (f *fasthttpConn) WriteFrame(fr *types.Frame) error {
+	pm, err := fr.Prepared(func(data []byte) (any, error) {
+		return websocket.NewPreparedMessage(websocket.TextMessage, data)
+	})
+	if err != nil {
+		return err
+	}
+	return f.conn.WritePreparedMessage(pm.(*websocket.PreparedMessage))
+}
+
+func (f *fasthttpConn) WritePing(deadline time.Time) error {
+	return f.conn.WriteControl(websocket.PingMessage, nil, deadline)
+}
+
+func (f *fasthttpConn) SetPongHandler(h func() error) {
+	f.conn.SetPongHandler(func(string) error { return h() })
+}
+
+func (f *fasthttpConn) CloseWithCode(code int, reason string) error {
+	msg := websocket.FormatCloseMessage(code, reason)
+	_ = f.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+	return f.conn.Close()
+}
+
+func (f *fasthttpConn) SetReadDeadline(t time.Time) error  { return f.conn.SetReadDeadline(t) }
+func (f *fasthttpConn) SetWriteDeadline(t time.Time) error { return f.conn.SetWriteDeadline(t) }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/orchestra-mcp/framework/app/plugins"
+	"github.com/orchestra-mcp/socket/src/hub"
 )
 
 // McpTools returns MCP tool definitions contributed by the WebSocket plugin.
@@ -27,8 +28,43 @@ func (p *SocketPlugin) McpTools() []plugins.McpToolDefinition {
 		{
 			Name:        "list_ws_channels",
 			Description: "List active WebSocket channels with subscriber counts",
-			InputSchema: map[string]any{},
-			Handler:     p.toolListChannels,
+			InputSchema: map[string]any{
+				"scope": map[string]any{"type": "string", "description": "\"local\" (default) or \"cluster\" to sum across instances"},
+			},
+			Handler: p.toolListChannels,
+		},
+		{
+			Name:        "ws_presence",
+			Description: "List members of a WebSocket presence channel",
+			InputSchema: map[string]any{
+				"channel": map[string]any{"type": "string", "description": "Presence channel name"},
+			},
+			Handler: p.toolPresence,
+		},
+		{
+			Name:        "ws_channel_history",
+			Description: "List recent messages retained for a WebSocket channel",
+			InputSchema: map[string]any{
+				"channel": map[string]any{"type": "string", "description": "Channel name"},
+				"limit":   map[string]any{"type": "integer", "description": "Newest N messages (default all retained)"},
+			},
+			Handler: p.toolChannelHistory,
+		},
+		{
+			Name:        "ws_locate_client",
+			Description: "Find which server instance holds a WebSocket client",
+			InputSchema: map[string]any{
+				"client_id": map[string]any{"type": "string", "description": "Client ID"},
+			},
+			Handler: p.toolLocateClient,
+		},
+		{
+			Name:        "ws_kick_client",
+			Description: "Disconnect a WebSocket client on whichever instance holds it",
+			InputSchema: map[string]any{
+				"client_id": map[string]any{"type": "string", "description": "Client ID"},
+			},
+			Handler: p.toolKickClient,
 		},
 	}
 }
@@ -69,11 +105,18 @@ func (p *SocketPlugin) toolPublish(input map[string]any) (any, error) {
 	return map[string]any{"published": true, "channel": channel}, nil
 }
 
-func (p *SocketPlugin) toolListChannels(_ map[string]any) (any, error) {
+func (p *SocketPlugin) toolListChannels(input map[string]any) (any, error) {
 	if p.service == nil {
 		return nil, fmt.Errorf("websocket service not initialized")
 	}
 	channels := p.service.GetChannels()
+	if scope, _ := input["scope"].(string); scope == "cluster" {
+		stats, err := p.service.GetClusterStats()
+		if err != nil {
+			return nil, err
+		}
+		channels = stats.Channels
+	}
 	result := make([]map[string]any, 0, len(channels))
 	for name, count := range channels {
 		result = append(result, map[string]any{
@@ -83,3 +126,56 @@ func (p *SocketPlugin) toolListChannels(_ map[string]any) (any, error) {
 	}
 	return map[string]any{"channels": result, "count": len(result)}, nil
 }
+
+func (p *SocketPlugin) toolPresence(input map[string]any) (any, error) {
+	if p.service == nil {
+		return nil, fmt.Errorf("websocket service not initialized")
+	}
+	channel, _ := input["channel"].(string)
+	if channel == "" {
+		return nil, fmt.Errorf("channel is required")
+	}
+	members, err := p.service.GetPresence(channel)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"channel": channel, "members": members, "count": len(members)}, nil
+}
+
+func (p *SocketPlugin) toolChannelHistory(input map[string]any) (any, error) {
+	if p.service == nil {
+		return nil, fmt.Errorf("websocket service not initialized")
+	}
+	channel, _ := input["channel"].(string)
+	if channel == "" {
+		return nil, fmt.Errorf("channel is required")
+	}
+	limit, _ := input["limit"].(float64)
+	messages := p.service.History(channel, hub.HistoryOptions{Limit: int(limit)})
+	return map[string]any{"channel": channel, "messages": messages, "count": len(messages)}, nil
+}
+
+func (p *SocketPlugin) toolLocateClient(input map[string]any) (any, error) {
+	if p.service == nil {
+		return nil, fmt.Errorf("websocket service not initialized")
+	}
+	clientID, _ := input["client_id"].(string)
+	if clientID == "" {
+		return nil, fmt.Errorf("client_id is required")
+	}
+	return p.service.LocateClient(clientID)
+}
+
+func (p *SocketPlugin) toolKickClient(input map[string]any) (any, error) {
+	if p.service == nil {
+		return nil, fmt.Errorf("websocket service not initialized")
+	}
+	clientID, _ := input["client_id"].(string)
+	if clientID == "" {
+		return nil, fmt.Errorf("client_id is required")
+	}
+	if err := p.service.KickClient(clientID); err != nil {
+		return nil, err
+	}
+	return map[string]any{"kicked": true, "client_id": clientID}, nil
+}
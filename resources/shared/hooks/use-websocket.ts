@@ -1,24 +1,43 @@
 /**
  * WebSocket client hook for the socket plugin.
- * Singleton connection with auto-reconnect, offline queue, and channel pub/sub.
+ * Singleton connection with auto-reconnect, session resume, offline queue,
+ * channel pub/sub, and RPC calls in both directions.
  */
 
 import { useCallback, useEffect, useRef, useState } from 'react';
-import type { WSMessage, WSOptions, WSStatus } from '../types/websocket';
+import type {
+  WSMessage,
+  WSOptions,
+  WSRPCError,
+  WSRPCMethod,
+  WSStatus,
+} from '../types/websocket';
 
 // ── Singleton State ───────────────────────────────────────────────
 
 type Listener = (message: WSMessage) => void;
 
+interface PendingCall {
+  resolve: (result: unknown) => void;
+  reject: (error: WSRPCError) => void;
+  timer: ReturnType<typeof setTimeout>;
+}
+
 let socket: WebSocket | null = null;
 let reconnectTimer: ReturnType<typeof setTimeout> | null = null;
 let retryCount = 0;
+let sessionToken: string | null = null;
 
 const listeners = new Map<string, Set<Listener>>();
 const offlineQueue: string[] = [];
+const pendingCalls = new Map<string, PendingCall>();
+const methods = new Map<string, WSRPCMethod>();
+let nextCallId = 0;
 
 const DEFAULT_URL = 'ws://localhost:8080/ws';
 const MAX_RETRY_DELAY = 30_000;
+const SYSTEM_CHANNEL = '$system';
+const DEFAULT_CALL_TIMEOUT = 10_000;
 
 // ── Helpers ───────────────────────────────────────────────────────
 
@@ -33,6 +52,77 @@ function flushQueue(): void {
   }
 }
 
+// Appends the last session token so the server restores the same client ID,
+// subscriptions, and missed messages after a reconnect.
+function withResume(url: string): string {
+  if (!sessionToken) return url;
+  const sep = url.includes('?') ? '&' : '?';
+  return `${url}${sep}resume=${encodeURIComponent(sessionToken)}`;
+}
+
+function trackSession(message: WSMessage): void {
+  if (message.channel !== SYSTEM_CHANNEL || message.event !== 'session') return;
+  const token = message.data?.token;
+  if (typeof token === 'string') sessionToken = token;
+}
+
+function sendFrame(frame: object): void {
+  const serialized = JSON.stringify(frame);
+  if (socket?.readyState === WebSocket.OPEN) {
+    socket.send(serialized);
+  } else {
+    offlineQueue.push(serialized);
+  }
+}
+
+// Settles calls made with `call` and answers calls made by the server.
+// Returns true when the frame was consumed.
+function handleRPC(message: WSMessage): boolean {
+  if (message.channel !== SYSTEM_CHANNEL || !message.id) return false;
+  if (message.event === 'rpc_result') {
+    const pending = pendingCalls.get(message.id);
+    if (!pending) return false;
+    pendingCalls.delete(message.id);
+    clearTimeout(pending.timer);
+    const data = message.data ?? {};
+    if (typeof data.code === 'string' || typeof data.error === 'string') {
+      pending.reject({
+        code: typeof data.code === 'string' ? data.code : 'handler_error',
+        message: typeof data.error === 'string' ? data.error : '',
+      });
+    } else {
+      pending.resolve(data.result);
+    }
+    return true;
+  }
+  if (message.event === 'rpc') {
+    answerCall(message.id, message.data ?? {});
+    return true;
+  }
+  return false;
+}
+
+function answerCall(id: string, data: Record<string, unknown>): void {
+  const name = String(data.method ?? '');
+  const reply = (result: Record<string, unknown>) =>
+    sendFrame({ id, channel: SYSTEM_CHANNEL, event: 'rpc_result', data: result });
+  const method = methods.get(name);
+  if (!method) {
+    reply({ code: 'method_not_found', error: `unknown method ${name}` });
+    return;
+  }
+  Promise.resolve()
+    .then(() => method(data.params))
+    .then(
+      (result) => reply({ result }),
+      (err) =>
+        reply({
+          code: 'handler_error',
+          error: err instanceof Error ? err.message : String(err),
+        }),
+    );
+}
+
 function dispatch(message: WSMessage): void {
   const channelListeners = listeners.get(message.channel);
   if (channelListeners) {
@@ -54,7 +144,7 @@ function connect(
   if (socket?.readyState === WebSocket.OPEN) return;
 
   onStatus('connecting');
-  socket = new WebSocket(url);
+  socket = new WebSocket(withResume(url));
 
   socket.onopen = () => {
     retryCount = 0;
@@ -65,7 +155,8 @@ function connect(
   socket.onmessage = (event) => {
     try {
       const message = JSON.parse(event.data) as WSMessage;
-      dispatch(message);
+      trackSession(message);
+      if (!handleRPC(message)) dispatch(message);
     } catch {
       // Ignore malformed messages
     }
@@ -97,6 +188,7 @@ function disconnect(): void {
     socket = null;
   }
   retryCount = 0;
+  sessionToken = null;
 }
 
 // ── Hook ──────────────────────────────────────────────────────────
@@ -143,12 +235,35 @@ export function useWebSocket(options?: WSOptions) {
   }, []);
 
   const send = useCallback((message: WSMessage) => {
-    const serialized = JSON.stringify(message);
-    if (socket?.readyState === WebSocket.OPEN) {
-      socket.send(serialized);
-    } else {
-      offlineQueue.push(serialized);
-    }
+    sendFrame(message);
+  }, []);
+
+  // Calls a server RPC method; rejects with a WSRPCError on failure.
+  const call = useCallback(
+    (method: string, params?: unknown, timeoutMs = DEFAULT_CALL_TIMEOUT) =>
+      new Promise<unknown>((resolve, reject) => {
+        const id = `c-${++nextCallId}`;
+        const timer = setTimeout(() => {
+          pendingCalls.delete(id);
+          reject({ code: 'timeout', message: 'call timed out' });
+        }, timeoutMs);
+        pendingCalls.set(id, { resolve, reject, timer });
+        sendFrame({
+          id,
+          channel: SYSTEM_CHANNEL,
+          event: 'rpc',
+          data: { method, params, timeout_ms: timeoutMs },
+        });
+      }),
+    [],
+  );
+
+  // Registers a method the server can call; returns an unregister function.
+  const handle = useCallback((method: string, fn: WSRPCMethod) => {
+    methods.set(method, fn);
+    return () => {
+      if (methods.get(method) === fn) methods.delete(method);
+    };
   }, []);
 
   const subscribe = useCallback((channel: string, listener: Listener) => {
@@ -169,5 +284,14 @@ export function useWebSocket(options?: WSOptions) {
     }
   }, []);
 
-  return { status, send, subscribe, unsubscribe, lastMessage, disconnect };
+  return {
+    status,
+    send,
+    subscribe,
+    unsubscribe,
+    call,
+    handle,
+    lastMessage,
+    disconnect,
+  };
 }
@@ -8,6 +8,11 @@ export interface WSMessage {
   channel: string;
   payload: unknown;
   timestamp: string;
+  /** Server frames carry the event name and data object. */
+  event?: string;
+  data?: Record<string, unknown>;
+  /** Request ID, echoed by handler replies and error frames. */
+  id?: string;
 }
 
 export interface WSOptions {
@@ -17,4 +22,13 @@ export interface WSOptions {
   channels?: string[];
 }
 
+/** Error answering an RPC call, from a server method or a client one. */
+export interface WSRPCError {
+  code: string;
+  message: string;
+}
+
+/** Client-side RPC method the server can call. */
+export type WSRPCMethod = (params: unknown) => unknown | Promise<unknown>;
+
 export type WSStatus = 'connecting' | 'connected' | 'disconnected' | 'error';
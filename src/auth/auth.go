@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+var (
+	// ErrUnauthorized rejects an upgrade with 401: credentials are missing or invalid.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrForbidden rejects an upgrade with 403: the caller is known but not allowed.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Request exposes the parts of an upgrade request an Authenticator may inspect.
+type Request interface {
+	Header(key string) string
+	Cookie(name string) string
+	Query(key string) string
+	RemoteAddr() string
+}
+
+// Authenticator resolves the identity behind a WebSocket upgrade request.
+// It runs before the connection is upgraded. Returning an error wrapping
+// ErrForbidden rejects with 403; any other error rejects with 401.
+type Authenticator interface {
+	Authenticate(req Request) (*types.Identity, error)
+}
+
+// AuthenticatorFunc adapts a plain function to the Authenticator interface.
+type AuthenticatorFunc func(req Request) (*types.Identity, error)
+
+// Authenticate calls f(req).
+func (f AuthenticatorFunc) Authenticate(req Request) (*types.Identity, error) {
+	return f(req)
+}
+
+// TokenValidator verifies a raw token and returns the identity it encodes.
+type TokenValidator func(token string) (*types.Identity, error)
+
+// TokenAuthenticator extracts a token from the Authorization bearer header,
+// then an optional cookie, then an optional query parameter, and hands the
+// first one found to Validate.
+type TokenAuthenticator struct {
+	Validate   TokenValidator
+	CookieName string // e.g. "session"; empty disables cookie lookup
+	QueryParam string // e.g. "token"; empty disables query lookup
+}
+
+// Authenticate implements Authenticator.
+func (a *TokenAuthenticator) Authenticate(req Request) (*types.Identity, error) {
+	token := a.token(req)
+	if token == "" {
+		return nil, ErrUnauthorized
+	}
+	return a.Validate(token)
+}
+
+func (a *TokenAuthenticator) token(req Request) string {
+	if h := req.Header("Authorization"); len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	if a.CookieName != "" {
+		if c := req.Cookie(a.CookieName); c != "" {
+			return c
+		}
+	}
+	if a.QueryParam != "" {
+		return req.Query(a.QueryParam)
+	}
+	return ""
+}
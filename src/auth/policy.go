@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// Action identifies the operation a ChannelAuthorizer is asked to approve.
+type Action string
+
+const (
+	ActionSubscribe Action = "subscribe" // join a channel and receive its messages
+	ActionPublish   Action = "publish"   // publish a client-attributed message
+	ActionInvoke    Action = "invoke"    // run the channel's registered handler
+)
+
+// Channel prefixes that require an authenticated client and an explicit rule.
+const (
+	PrivatePrefix  = "private-"
+	PresencePrefix = "presence-"
+)
+
+// ChannelAuthorizer decides whether a client may perform an action on a channel.
+// A nil error allows; errors should wrap ErrForbidden.
+type ChannelAuthorizer interface {
+	Authorize(client types.ClientInfo, channel string, action Action) error
+}
+
+// RuleFunc performs a dynamic check for a matching rule, e.g. comparing a
+// tenant ID embedded in the channel name against the client's claims.
+type RuleFunc func(client types.ClientInfo, channel string) bool
+
+type rule struct {
+	pattern string
+	actions []Action
+	allow   bool
+	check   RuleFunc
+}
+
+func (r rule) matches(channel string, action Action) bool {
+	if ok, _ := path.Match(r.pattern, channel); !ok {
+		return false
+	}
+	if len(r.actions) == 0 {
+		return true
+	}
+	for _, a := range r.actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Policy is a ChannelAuthorizer built from ordered pattern rules. Patterns
+// use path.Match syntax ("tenant-*", "private-doc-?"). The first rule
+// matching both channel and action decides; rules without actions match all.
+//
+// Channels prefixed with "private-" or "presence-" are always denied to
+// anonymous clients and are denied when no rule matches, regardless of
+// DefaultDeny. Other unmatched channels are allowed unless DefaultDeny is set.
+type Policy struct {
+	DefaultDeny bool
+	rules       []rule
+}
+
+// NewPolicy creates an empty policy. With defaultDeny, any channel not
+// matched by an allow rule is rejected.
+func NewPolicy(defaultDeny bool) *Policy {
+	return &Policy{DefaultDeny: defaultDeny}
+}
+
+// Allow permits the given actions (all if none) on channels matching pattern.
+func (p *Policy) Allow(pattern string, actions ...Action) *Policy {
+	p.rules = append(p.rules, rule{pattern: pattern, actions: actions, allow: true})
+	return p
+}
+
+// AllowIf permits the given actions on matching channels when check passes.
+// A failing check denies; later rules are not consulted.
+func (p *Policy) AllowIf(pattern string, check RuleFunc, actions ...Action) *Policy {
+	p.rules = append(p.rules, rule{pattern: pattern, actions: actions, allow: true, check: check})
+	return p
+}
+
+// Deny rejects the given actions (all if none) on channels matching pattern.
+func (p *Policy) Deny(pattern string, actions ...Action) *Policy {
+	p.rules = append(p.rules, rule{pattern: pattern, actions: actions})
+	return p
+}
+
+// Authorize implements ChannelAuthorizer.
+func (p *Policy) Authorize(client types.ClientInfo, channel string, action Action) error {
+	restricted := IsPrivate(channel) || IsPresence(channel)
+	if restricted && client.UserID == "" {
+		return deny(channel, action, "authentication required")
+	}
+
+	for _, r := range p.rules {
+		if !r.matches(channel, action) {
+			continue
+		}
+		if !r.allow {
+			return deny(channel, action, "denied by policy")
+		}
+		if r.check != nil && !r.check(client, channel) {
+			return deny(channel, action, "denied by policy")
+		}
+		return nil
+	}
+
+	if restricted || p.DefaultDeny {
+		return deny(channel, action, "no matching rule")
+	}
+	return nil
+}
+
+// IsPrivate reports whether channel carries the private- prefix.
+func IsPrivate(channel string) bool { return strings.HasPrefix(channel, PrivatePrefix) }
+
+// IsPresence reports whether channel carries the presence- prefix.
+func IsPresence(channel string) bool { return strings.HasPrefix(channel, PresencePrefix) }
+
+func deny(channel string, action Action, reason string) error {
+	return fmt.Errorf("%w: %s on %s: %s", ErrForbidden, action, channel, reason)
+}
@@ -1,6 +1,86 @@
 package bridge
 
-import "github.com/orchestra-mcp/socket/src/types"
+import (
+	"errors"
+	"sync"
+
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/rs/zerolog"
+)
+
+// ErrUnavailable is returned when a bridge cannot reach its backend and
+// has nowhere to buffer the message.
+var ErrUnavailable = errors.New("bridge unavailable")
+
+// envelope wraps a message with the originating instance ID so that a
+// node can skip its own published messages. All bridges share it.
+type envelope struct {
+	InstanceID string        `json:"instance_id"`
+	Target     string        `json:"target,omitempty"` // client ID for direct messages
+	Op         string        `json:"op,omitempty"`     // opKick for a kick; empty for a message
+	Message    types.Message `json:"message"`
+}
+
+// opKick marks a direct envelope that disconnects its target client.
+const opKick = "kick"
+
+// State describes a bridge's connection to its backend.
+type State string
+
+const (
+	StateConnecting State = "connecting" // started, not connected yet
+	StateConnected  State = "connected"
+	StateDegraded   State = "degraded" // connection lost, reconnecting
+	StateStopped    State = "stopped"
+)
+
+// stateTracker holds a bridge's connection state and notifies callbacks
+// of transitions. Bridges embed it to implement StateNotifier.
+type stateTracker struct {
+	stateMu     sync.RWMutex
+	state       State
+	onState     []func(State)
+	stateLogger zerolog.Logger
+	stateLevel  zerolog.Level // level transitions are logged at
+}
+
+// newStateTracker starts in StateConnecting.
+func newStateTracker(logger zerolog.Logger, level zerolog.Level) stateTracker {
+	return stateTracker{state: StateConnecting, stateLogger: logger, stateLevel: level}
+}
+
+// State reports the bridge's connection state.
+func (t *stateTracker) State() State {
+	t.stateMu.RLock()
+	defer t.stateMu.RUnlock()
+	return t.state
+}
+
+// OnStateChange registers a callback invoked on every state transition.
+// Callbacks run on the goroutine that changed the state and must not
+// block.
+func (t *stateTracker) OnStateChange(cb func(State)) {
+	t.stateMu.Lock()
+	defer t.stateMu.Unlock()
+	t.onState = append(t.onState, cb)
+}
+
+// setState moves to s and notifies callbacks. A stopped bridge stays stopped.
+func (t *stateTracker) setState(s State) {
+	t.stateMu.Lock()
+	if t.state == s || t.state == StateStopped {
+		t.stateMu.Unlock()
+		return
+	}
+	t.state = s
+	cbs := append([]func(State){}, t.onState...)
+	t.stateMu.Unlock()
+
+	t.stateLogger.WithLevel(t.stateLevel).Str("state", string(s)).Msg("bridge state changed")
+	for _, cb := range cbs {
+		cb(s)
+	}
+}
 
 // Bridge defines the interface for cross-instance message broadcasting.
 // Implementations relay messages between multiple server instances.
@@ -14,11 +94,36 @@ type Bridge interface {
 	// Stop shuts down the bridge connection.
 	Stop() error
 
-	// Available reports whether the bridge is connected and operational.
+	// Available reports whether the bridge currently accepts messages.
 	Available() bool
 }
 
+// StateNotifier is implemented by bridges that report connection state.
+type StateNotifier interface {
+	State() State
+	OnStateChange(cb func(State))
+}
+
 // BroadcastTarget is implemented by the Hub to receive messages from the bridge.
 type BroadcastTarget interface {
 	BroadcastToLocal(msg types.Message)
 }
+
+// DirectTarget is implemented by the Hub to receive messages addressed to
+// a single client connected to this instance.
+type DirectTarget interface {
+	SendToLocal(clientID string, msg types.Message) bool
+}
+
+// KickTarget is implemented by the Hub to disconnect a client held by this
+// instance on request from another one.
+type KickTarget interface {
+	KickLocal(clientID string) bool
+}
+
+// StatsSource is implemented by the Hub to report the local counts a
+// bridge publishes for cluster-wide statistics.
+type StatsSource interface {
+	ClientCount() int
+	Channels() map[string]int
+}
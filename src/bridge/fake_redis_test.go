@@ -0,0 +1,595 @@
+package bridge
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis speaks just enough RESP2 for the bridge: PING, SUBSCRIBE,
+// UNSUBSCRIBE, PUBLISH, their sharded S* forms, CLUSTER SLOTS, the
+// SET/GET/MGET/DEL/SCAN key commands and XADD/XREAD/XREVRANGE/XTRIM. It can be stopped and restarted on
+// the same address to simulate an outage.
+type fakeRedis struct {
+	t    *testing.T
+	addr string
+
+	mu        sync.Mutex
+	ln        net.Listener
+	subs      map[net.Conn]map[string]string // channel -> push kind
+	published []string                       // channels, in publish order
+	keys      map[string]fakeValue
+	sets      map[string]map[string]bool
+	streams   map[string][]fakeEntry
+	slots     string // CLUSTER SLOTS reply; empty when not a cluster
+}
+
+type fakeEntry struct {
+	ms, seq int64
+	fields  []string
+}
+
+func (e fakeEntry) id() string {
+	return strconv.FormatInt(e.ms, 10) + "-" + strconv.FormatInt(e.seq, 10)
+}
+
+// after reports whether e comes after the stream ID ms-seq.
+func (e fakeEntry) after(ms, seq int64) bool {
+	return e.ms > ms || (e.ms == ms && e.seq > seq)
+}
+
+type fakeValue struct {
+	data    string
+	expires time.Time // zero means no TTL
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	f := &fakeRedis{
+		t:       t,
+		subs:    map[net.Conn]map[string]string{},
+		keys:    map[string]fakeValue{},
+		sets:    map[string]map[string]bool{},
+		streams: map[string][]fakeEntry{},
+	}
+	f.start()
+	t.Cleanup(f.stop)
+	return f
+}
+
+func (f *fakeRedis) start() {
+	addr := f.addr
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		f.t.Fatalf("fake redis listen: %v", err)
+	}
+	f.mu.Lock()
+	f.ln = ln
+	f.addr = ln.Addr().String()
+	f.mu.Unlock()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.subs[conn] = map[string]string{}
+			f.mu.Unlock()
+			go f.serve(conn)
+		}
+	}()
+}
+
+// stop closes the listener and every open connection.
+func (f *fakeRedis) stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ln != nil {
+		_ = f.ln.Close()
+		f.ln = nil
+	}
+	for conn := range f.subs {
+		_ = conn.Close()
+	}
+	f.subs = map[net.Conn]map[string]string{}
+}
+
+// subscribed reports whether any connection is subscribed to channel.
+func (f *fakeRedis) subscribed(channel string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, chans := range f.subs {
+		if chans[channel] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeRedis) publishedTo() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.published...)
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer func() {
+		f.mu.Lock()
+		delete(f.subs, conn)
+		f.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		var out strings.Builder
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			out.WriteString("+PONG\r\n")
+		case "HELLO":
+			out.WriteString("-ERR unknown command 'HELLO'\r\n")
+		case "SUBSCRIBE", "UNSUBSCRIBE", "SSUBSCRIBE", "SUNSUBSCRIBE":
+			kind := strings.ToLower(args[0])
+			push := "message"
+			if kind[0] == 's' {
+				push = "smessage"
+			}
+			f.mu.Lock()
+			chans := f.subs[conn]
+			if chans == nil {
+				chans = map[string]string{}
+			}
+			for _, ch := range args[1:] {
+				if strings.HasSuffix(kind, "unsubscribe") {
+					delete(chans, ch)
+				} else {
+					chans[ch] = push
+				}
+				fmt.Fprintf(&out, "*3\r\n%s%s:%d\r\n", bulk(kind), bulk(ch), len(chans))
+			}
+			f.mu.Unlock()
+		case "PUBLISH", "SPUBLISH":
+			if len(args) != 3 {
+				out.WriteString("-ERR wrong number of arguments\r\n")
+				break
+			}
+			out.WriteString(":" + strconv.Itoa(f.deliver(args[1], args[2])) + "\r\n")
+		case "CLUSTER":
+			f.mu.Lock()
+			if f.slots == "" {
+				out.WriteString("-ERR This instance has cluster support disabled\r\n")
+			} else {
+				out.WriteString(f.slots)
+			}
+			f.mu.Unlock()
+		case "COMMAND":
+			out.WriteString("*0\r\n")
+		case "SET", "GET", "MGET", "DEL", "EXISTS", "SCAN":
+			out.WriteString(f.keyCommand(args))
+		case "SADD", "SREM", "SMEMBERS", "SISMEMBER":
+			out.WriteString(f.setCommand(args))
+		case "XADD", "XREVRANGE", "XTRIM":
+			out.WriteString(f.streamCommand(args))
+		case "XREAD":
+			out.WriteString(f.xread(args))
+		default:
+			out.WriteString("+OK\r\n")
+		}
+		// Writes share mu with deliver so pushed messages never interleave.
+		f.mu.Lock()
+		_, err = io.WriteString(conn, out.String())
+		f.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// deliver pushes a published message to subscribers and returns their count.
+func (f *fakeRedis) deliver(channel, payload string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.published = append(f.published, channel)
+	n := 0
+	for conn, chans := range f.subs {
+		if push := chans[channel]; push != "" {
+			n++
+			frame := "*3\r\n" + bulk(push) + bulk(channel) + bulk(payload)
+			_, _ = io.WriteString(conn, frame)
+		}
+	}
+	return n
+}
+
+// keyCommand runs a key command and returns its RESP reply.
+func (f *fakeRedis) keyCommand(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	get := func(key string) (string, bool) {
+		v, ok := f.keys[key]
+		if !ok || (!v.expires.IsZero() && now.After(v.expires)) {
+			return "", false
+		}
+		return v.data, true
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "SET": // key value [EX s|PX ms] [NX] [GET]
+		v := fakeValue{data: args[2]}
+		var nx, getOld bool
+		for i := 3; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "EX", "PX":
+				n, _ := strconv.Atoi(args[i+1])
+				unit := time.Second
+				if strings.EqualFold(args[i], "PX") {
+					unit = time.Millisecond
+				}
+				v.expires = now.Add(time.Duration(n) * unit)
+				i++
+			case "NX":
+				nx = true
+			case "GET":
+				getOld = true
+			}
+		}
+		old, exists := get(args[1])
+		if nx && exists {
+			return "$-1\r\n"
+		}
+		f.keys[args[1]] = v
+		switch {
+		case getOld && exists:
+			return bulk(old)
+		case getOld:
+			return "$-1\r\n"
+		}
+		return "+OK\r\n"
+	case "GET":
+		if data, ok := get(args[1]); ok {
+			return bulk(data)
+		}
+		return "$-1\r\n"
+	case "MGET":
+		out := "*" + strconv.Itoa(len(args)-1) + "\r\n"
+		for _, key := range args[1:] {
+			if data, ok := get(key); ok {
+				out += bulk(data)
+			} else {
+				out += "$-1\r\n"
+			}
+		}
+		return out
+	case "DEL", "EXISTS":
+		n := 0
+		for _, key := range args[1:] {
+			if _, ok := get(key); ok || f.sets[key] != nil {
+				n++
+			}
+			if strings.EqualFold(args[0], "DEL") {
+				delete(f.keys, key)
+				delete(f.sets, key)
+			}
+		}
+		return ":" + strconv.Itoa(n) + "\r\n"
+	default: // SCAN cursor [MATCH pattern] [COUNT n]; one pass, cursor 0
+		pattern := "*"
+		for i := 2; i+1 < len(args); i += 2 {
+			if strings.EqualFold(args[i], "MATCH") {
+				pattern = args[i+1]
+			}
+		}
+		var matched []string
+		for key := range f.keys {
+			if _, ok := get(key); !ok {
+				continue
+			}
+			if ok, _ := path.Match(pattern, key); ok {
+				matched = append(matched, key)
+			}
+		}
+		for key := range f.sets {
+			if ok, _ := path.Match(pattern, key); ok {
+				matched = append(matched, key)
+			}
+		}
+		out := "*2\r\n" + bulk("0") + "*" + strconv.Itoa(len(matched)) + "\r\n"
+		for _, key := range matched {
+			out += bulk(key)
+		}
+		return out
+	}
+}
+
+// setCommand runs SADD, SREM, SMEMBERS or SISMEMBER and returns its RESP
+// reply. An emptied set is deleted, as in Redis.
+func (f *fakeRedis) setCommand(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := args[1]
+	set := f.sets[key]
+
+	switch strings.ToUpper(args[0]) {
+	case "SADD":
+		if set == nil {
+			set = map[string]bool{}
+			f.sets[key] = set
+		}
+		n := 0
+		for _, m := range args[2:] {
+			if !set[m] {
+				set[m] = true
+				n++
+			}
+		}
+		return ":" + strconv.Itoa(n) + "\r\n"
+	case "SREM":
+		n := 0
+		for _, m := range args[2:] {
+			if set[m] {
+				delete(set, m)
+				n++
+			}
+		}
+		if set != nil && len(set) == 0 {
+			delete(f.sets, key)
+		}
+		return ":" + strconv.Itoa(n) + "\r\n"
+	case "SISMEMBER":
+		if set[args[2]] {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	default: // SMEMBERS
+		out := "*" + strconv.Itoa(len(set)) + "\r\n"
+		for m := range set {
+			out += bulk(m)
+		}
+		return out
+	}
+}
+
+// members returns the members of a set, empty if it does not exist.
+func (f *fakeRedis) members(key string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []string
+	for m := range f.sets[key] {
+		out = append(out, m)
+	}
+	return out
+}
+
+// streamCommand runs XADD, XREVRANGE or XTRIM and returns its RESP reply.
+// Approximate trimming is exact here.
+func (f *fakeRedis) streamCommand(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := args[1]
+	entries := f.streams[key]
+
+	switch strings.ToUpper(args[0]) {
+	case "XADD": // key [MAXLEN|MINID [~|=] n] * field value ...
+		var maxLen int
+		var minMs int64 = -1
+		i := 2
+		for ; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "MAXLEN", "MINID":
+				kind := strings.ToUpper(args[i])
+				if args[i+1] == "~" || args[i+1] == "=" {
+					i++
+				}
+				i++
+				if kind == "MAXLEN" {
+					maxLen, _ = strconv.Atoi(args[i])
+				} else {
+					minMs, _ = parseStreamID(args[i])
+				}
+				continue
+			}
+			break
+		}
+		e := fakeEntry{ms: time.Now().UnixMilli(), fields: args[i+1:]}
+		if n := len(entries); n > 0 && entries[n-1].ms >= e.ms {
+			e.ms, e.seq = entries[n-1].ms, entries[n-1].seq+1
+		}
+		entries = append(entries, e)
+		if maxLen > 0 && len(entries) > maxLen {
+			entries = entries[len(entries)-maxLen:]
+		}
+		if minMs >= 0 {
+			entries = trimBefore(entries, minMs)
+		}
+		f.streams[key] = entries
+		return bulk(e.id())
+	case "XREVRANGE": // key + - [COUNT n]
+		n := len(entries)
+		if len(args) >= 6 {
+			n, _ = strconv.Atoi(args[5])
+		}
+		var rev []fakeEntry
+		for i := len(entries) - 1; i >= 0 && len(rev) < n; i-- {
+			rev = append(rev, entries[i])
+		}
+		return entriesReply(rev)
+	default: // XTRIM key MINID [~|=] id [LIMIT n]
+		i := 3
+		if args[i] == "~" || args[i] == "=" {
+			i++
+		}
+		minMs, _ := parseStreamID(args[i])
+		kept := trimBefore(entries, minMs)
+		f.streams[key] = kept
+		return ":" + strconv.Itoa(len(entries)-len(kept)) + "\r\n"
+	}
+}
+
+// xread serves XREAD [COUNT n] [BLOCK ms] STREAMS key id for one stream,
+// polling until entries arrive or the block time passes.
+func (f *fakeRedis) xread(args []string) string {
+	count, block := 0, -1
+	var key, from string
+	for i := 1; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "COUNT":
+			count, _ = strconv.Atoi(args[i+1])
+			i++
+		case "BLOCK":
+			block, _ = strconv.Atoi(args[i+1])
+			i++
+		case "STREAMS":
+			key, from = args[i+1], args[i+2]
+			i = len(args)
+		}
+	}
+	deadline := time.Now().Add(time.Duration(block) * time.Millisecond)
+	for {
+		f.mu.Lock()
+		entries := f.streams[key]
+		ms, seq := parseStreamID(from)
+		if from == "$" && len(entries) > 0 {
+			last := entries[len(entries)-1]
+			ms, seq = last.ms, last.seq
+		}
+		var found []fakeEntry
+		for _, e := range entries {
+			if e.after(ms, seq) && (count == 0 || len(found) < count) {
+				found = append(found, e)
+			}
+		}
+		f.mu.Unlock()
+
+		if len(found) > 0 {
+			return "*1\r\n*2\r\n" + bulk(key) + entriesReply(found)
+		}
+		if block < 0 || time.Now().After(deadline) {
+			return "*-1\r\n"
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+// streamLen returns the number of entries in a stream.
+func (f *fakeRedis) streamLen(key string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.streams[key])
+}
+
+func parseStreamID(id string) (ms, seq int64) {
+	msPart, seqPart, _ := strings.Cut(id, "-")
+	ms, _ = strconv.ParseInt(msPart, 10, 64)
+	seq, _ = strconv.ParseInt(seqPart, 10, 64)
+	return ms, seq
+}
+
+func trimBefore(entries []fakeEntry, minMs int64) []fakeEntry {
+	for len(entries) > 0 && entries[0].ms < minMs {
+		entries = entries[1:]
+	}
+	return entries
+}
+
+func entriesReply(entries []fakeEntry) string {
+	out := "*" + strconv.Itoa(len(entries)) + "\r\n"
+	for _, e := range entries {
+		out += "*2\r\n" + bulk(e.id()) + "*" + strconv.Itoa(len(e.fields)) + "\r\n"
+		for _, field := range e.fields {
+			out += bulk(field)
+		}
+	}
+	return out
+}
+
+// clusterSlots makes each fake answer CLUSTER SLOTS with the hash slots
+// split evenly between them, so a ClusterClient treats them as one cluster.
+func clusterSlots(nodes ...*fakeRedis) {
+	reply := "*" + strconv.Itoa(len(nodes)) + "\r\n"
+	per := 16384 / len(nodes)
+	for i, n := range nodes {
+		end := (i+1)*per - 1
+		if i == len(nodes)-1 {
+			end = 16383
+		}
+		host, port, _ := net.SplitHostPort(n.addr)
+		reply += fmt.Sprintf("*3\r\n:%d\r\n:%d\r\n*3\r\n%s:%s\r\n%s", i*per, end, bulk(host), port, bulk("node-"+strconv.Itoa(i)))
+	}
+	for _, n := range nodes {
+		n.mu.Lock()
+		n.slots = reply
+		n.mu.Unlock()
+	}
+}
+
+// value returns a key's stored data, ignoring expiry.
+func (f *fakeRedis) value(key string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.keys[key]
+	return v.data, ok
+}
+
+// expiry returns when a key expires, zero if it never does.
+func (f *fakeRedis) expiry(key string) time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.keys[key].expires
+}
+
+func bulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+// readCommand reads one RESP array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil // inline command
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, n)
+	for range n {
+		head, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimRight(head, "\r\n")[1:])
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
@@ -0,0 +1,421 @@
+package bridge
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/rs/zerolog"
+)
+
+// Mesh links MemoryBridges in one process, standing in for a message
+// broker. Latency, message loss and network partitions can be injected so
+// multi-hub behaviour is testable without external services. Loss is
+// drawn from a seeded source, so a given seed drops the same messages on
+// every run.
+type Mesh struct {
+	mu      sync.Mutex
+	nodes   map[string]*MemoryBridge
+	latency time.Duration
+	loss    float64
+	rng     *rand.Rand
+	cut     map[[2]string]bool // partitioned node pairs, ordered by ID
+
+	pendingMu sync.Mutex
+	pending   int
+	idle      *sync.Cond
+}
+
+// NewMesh creates an empty mesh. seed drives loss injection.
+func NewMesh(seed int64) *Mesh {
+	m := &Mesh{
+		nodes: make(map[string]*MemoryBridge),
+		rng:   rand.New(rand.NewSource(seed)),
+		cut:   make(map[[2]string]bool),
+	}
+	m.idle = sync.NewCond(&m.pendingMu)
+	return m
+}
+
+// SetLatency delays every delivery by d.
+func (m *Mesh) SetLatency(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latency = d
+}
+
+// SetLoss drops each delivery with probability p (0 to 1).
+func (m *Mesh) SetLoss(p float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loss = p
+}
+
+// Partition cuts the link between two nodes in both directions.
+func (m *Mesh) Partition(a, b string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cut[linkKey(a, b)] = true
+}
+
+// Heal restores the link between two nodes.
+func (m *Mesh) Heal(a, b string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.cut, linkKey(a, b))
+}
+
+// Wait blocks until every message sent so far has been handed to its
+// target hub or dropped.
+func (m *Mesh) Wait() {
+	m.pendingMu.Lock()
+	defer m.pendingMu.Unlock()
+	for m.pending > 0 {
+		m.idle.Wait()
+	}
+}
+
+func (m *Mesh) track(n int) {
+	m.pendingMu.Lock()
+	defer m.pendingMu.Unlock()
+	m.pending += n
+	if m.pending == 0 {
+		m.idle.Broadcast()
+	}
+}
+
+func linkKey(a, b string) [2]string {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
+
+// reachableLocked lists the nodes other than from that are not partitioned
+// from it and whose delivery survives loss injection. Nodes are visited in
+// ID order so a seed draws the same losses for the same nodes on every
+// run. The caller holds mu.
+func (m *Mesh) reachableLocked(from string) []*MemoryBridge {
+	ids := make([]string, 0, len(m.nodes))
+	for id := range m.nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var out []*MemoryBridge
+	for _, id := range ids {
+		if id == from || m.cut[linkKey(from, id)] {
+			continue
+		}
+		if m.loss > 0 && m.rng.Float64() < m.loss {
+			continue
+		}
+		out = append(out, m.nodes[id])
+	}
+	return out
+}
+
+// MemoryBridge is an in-process Bridge attached to a Mesh. It implements
+// the same optional interfaces as RedisBridge: per-channel interest,
+// direct messages, the client directory and cluster stats.
+type MemoryBridge struct {
+	mesh    *Mesh
+	id      string
+	hub     BroadcastTarget
+	logger  zerolog.Logger
+	started time.Time
+	stateTracker
+
+	mu       sync.Mutex
+	watching map[string]bool
+	clients  map[string]bool
+	queue    []delayed
+	wake     chan struct{}
+	done     chan struct{}
+}
+
+// NewMemoryBridge creates a bridge that joins mesh as node id on Start.
+func NewMemoryBridge(mesh *Mesh, id string, hub BroadcastTarget, logger zerolog.Logger) *MemoryBridge {
+	logger = logger.With().Str("component", "memory-bridge").Str("node", id).Logger()
+	return &MemoryBridge{
+		mesh:         mesh,
+		id:           id,
+		hub:          hub,
+		logger:       logger,
+		started:      time.Now(),
+		stateTracker: newStateTracker(logger, zerolog.DebugLevel),
+		watching:     make(map[string]bool),
+		clients:      make(map[string]bool),
+		wake:         make(chan struct{}, 1),
+		done:         make(chan struct{}),
+	}
+}
+
+// ID returns the node's name on the mesh.
+func (b *MemoryBridge) ID() string { return b.id }
+
+// NodeID returns the node's name on the mesh.
+func (b *MemoryBridge) NodeID() string { return b.id }
+
+// Start joins the mesh and begins delivering messages to the hub.
+func (b *MemoryBridge) Start() error {
+	b.mesh.mu.Lock()
+	b.mesh.nodes[b.id] = b
+	b.mesh.mu.Unlock()
+
+	go b.deliverLoop()
+	b.setState(StateConnected)
+	return nil
+}
+
+// Stop leaves the mesh. Queued messages are dropped.
+func (b *MemoryBridge) Stop() error {
+	b.mesh.mu.Lock()
+	if b.mesh.nodes[b.id] == b {
+		delete(b.mesh.nodes, b.id)
+	}
+	b.mesh.mu.Unlock()
+
+	b.setState(StateStopped)
+	b.mu.Lock()
+	dropped := len(b.queue)
+	b.queue = nil
+	select {
+	case <-b.done:
+	default:
+		close(b.done)
+	}
+	b.mu.Unlock()
+	b.mesh.track(-dropped)
+	return nil
+}
+
+// Publish sends a message to every reachable node watching its channel.
+func (b *MemoryBridge) Publish(msg types.Message) error {
+	if b.State() != StateConnected {
+		return ErrUnavailable
+	}
+	b.mesh.mu.Lock()
+	targets := b.mesh.reachableLocked(b.id)
+	latency := b.mesh.latency
+	b.mesh.mu.Unlock()
+
+	for _, n := range targets {
+		n.enqueue(msg, latency)
+	}
+	return nil
+}
+
+// delayed is a queued message and the earliest time it may be delivered.
+type delayed struct {
+	msg types.Message
+	due time.Time
+}
+
+// enqueue schedules msg for delivery to this node's hub after latency.
+// Messages are delivered in the order they were enqueued; one never
+// overtakes an earlier one, even if the latency changed in between.
+func (b *MemoryBridge) enqueue(msg types.Message, latency time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.State() != StateConnected || !b.watching[msg.Channel] {
+		return
+	}
+	b.mesh.track(1)
+	b.queue = append(b.queue, delayed{msg: msg, due: time.Now().Add(latency)})
+	b.signal()
+}
+
+func (b *MemoryBridge) signal() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliverLoop hands queued messages to the hub in order, each once its
+// latency has passed. Delivery runs on its own goroutine so a hub
+// publishing to the mesh never blocks on another hub's queues.
+func (b *MemoryBridge) deliverLoop() {
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-b.wake:
+		}
+		for {
+			b.mu.Lock()
+			if len(b.queue) == 0 {
+				b.mu.Unlock()
+				break
+			}
+			next := b.queue[0]
+			b.mu.Unlock()
+
+			if wait := time.Until(next.due); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-b.done:
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+			}
+
+			b.mu.Lock()
+			if len(b.queue) == 0 {
+				b.mu.Unlock() // Stop dropped the queue meanwhile
+				break
+			}
+			b.queue = b.queue[1:]
+			b.mu.Unlock()
+
+			b.hub.BroadcastToLocal(next.msg)
+			b.mesh.track(-1)
+		}
+	}
+}
+
+// Available reports whether the node is on the mesh.
+func (b *MemoryBridge) Available() bool {
+	return b.State() == StateConnected
+}
+
+// Watch starts accepting a channel's messages.
+func (b *MemoryBridge) Watch(channel string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.watching[channel] = true
+	return nil
+}
+
+// Unwatch stops accepting a channel's messages.
+func (b *MemoryBridge) Unwatch(channel string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.watching, channel)
+	return nil
+}
+
+// Attach announces a local client for direct messages.
+func (b *MemoryBridge) Attach(clientID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clients[clientID] = true
+	return nil
+}
+
+// Detach withdraws a local client.
+func (b *MemoryBridge) Detach(clientID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.clients, clientID)
+	return nil
+}
+
+// SendDirect delivers msg to the reachable node holding clientID after the
+// mesh latency and reports whether that node's hub accepted it.
+func (b *MemoryBridge) SendDirect(clientID string, msg types.Message) (bool, error) {
+	if b.State() != StateConnected {
+		return false, ErrUnavailable
+	}
+	b.mesh.mu.Lock()
+	targets := b.mesh.reachableLocked(b.id)
+	latency := b.mesh.latency
+	b.mesh.mu.Unlock()
+
+	for _, n := range targets {
+		if !n.holds(clientID) {
+			continue
+		}
+		dt, ok := n.hub.(DirectTarget)
+		if !ok {
+			return false, nil
+		}
+		time.Sleep(latency)
+		return dt.SendToLocal(clientID, msg), nil
+	}
+	return false, nil
+}
+
+// Locate returns the node holding clientID, searching this node and every
+// node it can reach. Loss injection does not apply.
+func (b *MemoryBridge) Locate(clientID string) (string, bool, error) {
+	if b.State() != StateConnected {
+		return "", false, ErrUnavailable
+	}
+	for _, n := range b.linked() {
+		if n.holds(clientID) {
+			return n.id, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// Kick disconnects clientID on the reachable node holding it after the
+// mesh latency and reports whether that node's hub held it.
+func (b *MemoryBridge) Kick(clientID string) (bool, error) {
+	if b.State() != StateConnected {
+		return false, ErrUnavailable
+	}
+	b.mesh.mu.Lock()
+	latency := b.mesh.latency
+	b.mesh.mu.Unlock()
+
+	for _, n := range b.linked() {
+		if !n.holds(clientID) {
+			continue
+		}
+		kt, ok := n.hub.(KickTarget)
+		if !ok {
+			return false, nil
+		}
+		time.Sleep(latency)
+		return kt.KickLocal(clientID), nil
+	}
+	return false, nil
+}
+
+// linked lists this node and every node not partitioned from it.
+func (b *MemoryBridge) linked() []*MemoryBridge {
+	b.mesh.mu.Lock()
+	defer b.mesh.mu.Unlock()
+	var nodes []*MemoryBridge
+	for id, n := range b.mesh.nodes {
+		if id == b.id || !b.mesh.cut[linkKey(b.id, id)] {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+func (b *MemoryBridge) holds(clientID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.clients[clientID]
+}
+
+// NodeStats reports this node and every node it can reach. Loss injection
+// does not apply.
+func (b *MemoryBridge) NodeStats() ([]types.NodeStats, error) {
+	if b.State() != StateConnected {
+		return nil, ErrUnavailable
+	}
+	var stats []types.NodeStats
+	for _, n := range b.linked() {
+		src, ok := n.hub.(StatsSource)
+		if !ok {
+			continue
+		}
+		stats = append(stats, types.NodeStats{
+			InstanceID: n.id,
+			StartedAt:  n.started,
+			Clients:    src.ClientCount(),
+			Channels:   src.Channels(),
+			UpdatedAt:  time.Now(),
+		})
+	}
+	return stats, nil
+}
@@ -0,0 +1,435 @@
+package bridge
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/rs/zerolog"
+)
+
+// NatsBridge relays WebSocket messages between server instances via NATS.
+//
+// Channel messages go to <subject>.channel.<name> and are skipped by the
+// instance that published them. Direct messages use request/reply on
+// <subject>.client.<id>, so SendDirect learns whether the client's
+// instance delivered the message. With JetStream enabled, channel
+// messages are stored in a stream and each watched channel is read
+// through an ordered consumer that resumes where it left off after a
+// reconnect.
+//
+// The NATS client reconnects and resubscribes on its own; the bridge maps
+// its connection events onto State.
+type NatsBridge struct {
+	cfg        NatsConfig
+	instanceID string
+	hub        BroadcastTarget
+	logger     zerolog.Logger
+	stateTracker
+
+	mu       sync.RWMutex
+	nc       *nats.Conn
+	js       jetstream.JetStream
+	channels map[string]func()             // watched channel -> stop; nil until consuming
+	clients  map[string]*nats.Subscription // nil until subscribed
+}
+
+// NewNatsBridge creates a bridge that uses NATS for cross-instance messaging.
+func NewNatsBridge(cfg *NatsConfig, hub BroadcastTarget, logger zerolog.Logger) *NatsBridge {
+	c := *cfg
+	defaults := DefaultNatsConfig()
+	if c.URL == "" {
+		c.URL = defaults.URL
+	}
+	if c.Subject == "" {
+		c.Subject = defaults.Subject
+	}
+	if c.Stream == "" {
+		c.Stream = defaults.Stream
+	}
+	if c.MaxAge <= 0 {
+		c.MaxAge = defaults.MaxAge
+	}
+	if c.RequestTimeout <= 0 {
+		c.RequestTimeout = defaults.RequestTimeout
+	}
+	if c.ReconnectWait <= 0 {
+		c.ReconnectWait = defaults.ReconnectWait
+	}
+
+	logger = logger.With().Str("component", "nats-bridge").Logger()
+	return &NatsBridge{
+		cfg:          c,
+		instanceID:   uuid.New().String(),
+		hub:          hub,
+		logger:       logger,
+		stateTracker: newStateTracker(logger, zerolog.InfoLevel),
+		channels:     make(map[string]func()),
+		clients:      make(map[string]*nats.Subscription),
+	}
+}
+
+// Start connects to NATS. An unreachable server is not an error: the
+// client keeps retrying in the background and the bridge stays in
+// StateConnecting until it succeeds.
+func (b *NatsBridge) Start() error {
+	opts := []nats.Option{
+		nats.Name(b.cfg.Name),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(b.cfg.ReconnectWait),
+		nats.ConnectHandler(func(*nats.Conn) { b.onConnected() }),
+		nats.ReconnectHandler(func(*nats.Conn) { b.onConnected() }),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				b.logger.Warn().Err(err).Msg("nats connection lost")
+			}
+			b.setState(StateDegraded)
+		}),
+		nats.ClosedHandler(func(*nats.Conn) { b.setState(StateStopped) }),
+	}
+	if b.cfg.ReconnectBufSize != 0 {
+		opts = append(opts, nats.ReconnectBufSize(b.cfg.ReconnectBufSize))
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	nc, err := nats.Connect(b.cfg.URL, opts...)
+	if err != nil {
+		return err
+	}
+	b.nc = nc
+	if b.cfg.JetStream {
+		js, err := jetstream.New(nc, jetstream.WithPublishAsyncErrHandler(
+			func(_ jetstream.JetStream, m *nats.Msg, err error) {
+				b.logger.Error().Err(err).Str("subject", m.Subject).Msg("jetstream publish failed")
+			}))
+		if err != nil {
+			nc.Close()
+			return err
+		}
+		b.js = js
+	}
+	b.subscribePendingLocked()
+
+	b.logger.Info().
+		Str("instance_id", b.instanceID).
+		Str("subject", b.cfg.Subject).
+		Bool("jetstream", b.cfg.JetStream).
+		Msg("nats bridge started")
+	return nil
+}
+
+// subscribePendingLocked subscribes clients and, without JetStream,
+// channels registered before Start. The caller holds mu.
+func (b *NatsBridge) subscribePendingLocked() {
+	for id, sub := range b.clients {
+		if sub == nil {
+			if err := b.attachLocked(id); err != nil {
+				b.logger.Warn().Err(err).Str("client_id", id).Msg("failed to attach client")
+			}
+		}
+	}
+	if b.js != nil {
+		return
+	}
+	for ch, stop := range b.channels {
+		if stop == nil {
+			if err := b.watchLocked(ch); err != nil {
+				b.logger.Warn().Err(err).Str("channel", ch).Msg("failed to watch channel")
+			}
+		}
+	}
+}
+
+// onConnected runs on the initial connection and every reconnect. Core
+// subscriptions are restored by the client; JetStream needs the stream to
+// exist before channels waiting for it can start consuming.
+func (b *NatsBridge) onConnected() {
+	if b.cfg.JetStream {
+		if err := b.ensureStream(); err != nil {
+			b.logger.Error().Err(err).Msg("failed to create jetstream stream")
+		} else {
+			b.mu.Lock()
+			for ch, stop := range b.channels {
+				if stop == nil {
+					if err := b.watchLocked(ch); err != nil {
+						b.logger.Warn().Err(err).Str("channel", ch).Msg("failed to consume channel")
+					}
+				}
+			}
+			b.mu.Unlock()
+		}
+	}
+	b.setState(StateConnected)
+}
+
+// ensureStream creates or updates the stream holding channel messages.
+func (b *NatsBridge) ensureStream() error {
+	b.mu.RLock()
+	js := b.js
+	b.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.cfg.RequestTimeout)
+	defer cancel()
+	_, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     b.cfg.Stream,
+		Subjects: []string{b.cfg.Subject + ".channel.>"},
+		MaxAge:   b.cfg.MaxAge,
+	})
+	return err
+}
+
+// Publish sends a message to the instances watching its channel.
+func (b *NatsBridge) Publish(msg types.Message) error {
+	data, err := json.Marshal(envelope{InstanceID: b.instanceID, Message: msg})
+	if err != nil {
+		return err
+	}
+
+	b.mu.RLock()
+	nc, js := b.nc, b.js
+	b.mu.RUnlock()
+	if nc == nil {
+		return ErrUnavailable
+	}
+	subject := b.channelSubject(msg.Channel)
+	if js != nil {
+		// Acks are awaited asynchronously; failures reach the error handler.
+		_, err = js.PublishAsync(subject, data)
+		return err
+	}
+	return nc.Publish(subject, data)
+}
+
+// Watch starts receiving a channel's messages from other instances.
+func (b *NatsBridge) Watch(channel string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.channels[channel]; ok {
+		return nil
+	}
+	b.channels[channel] = nil
+	if b.nc == nil || (b.js != nil && b.State() != StateConnected) {
+		return nil // picked up by Start or onConnected
+	}
+	return b.watchLocked(channel)
+}
+
+// watchLocked subscribes to a channel. The caller holds mu.
+func (b *NatsBridge) watchLocked(channel string) error {
+	subject := b.channelSubject(channel)
+
+	if b.js == nil {
+		sub, err := b.nc.Subscribe(subject, func(m *nats.Msg) { b.handleChannel(m.Data) })
+		if err != nil {
+			return err
+		}
+		b.channels[channel] = func() {
+			_ = sub.Unsubscribe()
+			b.flush()
+		}
+		return b.flush()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.cfg.RequestTimeout)
+	defer cancel()
+	cons, err := b.js.OrderedConsumer(ctx, b.cfg.Stream, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{subject},
+		DeliverPolicy:  jetstream.DeliverNewPolicy,
+	})
+	if err != nil {
+		return err
+	}
+	cc, err := cons.Consume(func(m jetstream.Msg) { b.handleChannel(m.Data()) })
+	if err != nil {
+		return err
+	}
+	b.channels[channel] = cc.Stop
+	return nil
+}
+
+// Unwatch stops receiving a channel's messages.
+func (b *NatsBridge) Unwatch(channel string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if stop := b.channels[channel]; stop != nil {
+		stop()
+	}
+	delete(b.channels, channel)
+	return nil
+}
+
+// Attach answers direct-message requests for a local client.
+func (b *NatsBridge) Attach(clientID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.clients[clientID]; ok {
+		return nil
+	}
+	b.clients[clientID] = nil
+	if b.nc == nil {
+		return nil // picked up by Start
+	}
+	return b.attachLocked(clientID)
+}
+
+// attachLocked subscribes to a client's subject. The caller holds mu.
+func (b *NatsBridge) attachLocked(clientID string) error {
+	sub, err := b.nc.Subscribe(b.clientSubject(clientID), b.handleDirect)
+	if err != nil {
+		return err
+	}
+	b.clients[clientID] = sub
+	return b.flush()
+}
+
+// Detach stops answering direct messages for a client.
+func (b *NatsBridge) Detach(clientID string) error {
+	b.mu.Lock()
+	sub, ok := b.clients[clientID]
+	delete(b.clients, clientID)
+	b.mu.Unlock()
+	if !ok || sub == nil {
+		return nil
+	}
+	if err := sub.Unsubscribe(); err != nil {
+		return err
+	}
+	return b.flush()
+}
+
+// flush waits until the server has processed pending subscription changes,
+// so they are in effect when Watch, Attach or their inverses return. It is
+// a no-op while disconnected; the client replays subscriptions on reconnect.
+func (b *NatsBridge) flush() error {
+	if !b.nc.IsConnected() {
+		return nil
+	}
+	return b.nc.FlushTimeout(b.cfg.RequestTimeout)
+}
+
+// SendDirect asks the instance holding clientID to deliver msg and reports
+// whether it did. No responder means no instance holds the client.
+func (b *NatsBridge) SendDirect(clientID string, msg types.Message) (bool, error) {
+	b.mu.RLock()
+	nc := b.nc
+	b.mu.RUnlock()
+	if nc == nil || !nc.IsConnected() {
+		return false, ErrUnavailable
+	}
+
+	data, err := json.Marshal(envelope{InstanceID: b.instanceID, Target: clientID, Message: msg})
+	if err != nil {
+		return false, err
+	}
+	reply, err := nc.Request(b.clientSubject(clientID), data, b.cfg.RequestTimeout)
+	if errors.Is(err, nats.ErrNoResponders) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return string(reply.Data) == "1", nil
+}
+
+// Stop stops consuming and closes the NATS connection.
+func (b *NatsBridge) Stop() error {
+	b.setState(StateStopped)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch, stop := range b.channels {
+		if stop != nil {
+			stop()
+			b.channels[ch] = nil
+		}
+	}
+	if b.nc != nil {
+		b.nc.Close()
+	}
+	return nil
+}
+
+// Available reports whether the bridge accepts publishes: it is connected,
+// or it is reconnecting and the client buffers outgoing messages.
+func (b *NatsBridge) Available() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	switch b.State() {
+	case StateConnected:
+		return true
+	case StateConnecting, StateDegraded:
+		return b.nc != nil && b.cfg.ReconnectBufSize >= 0
+	default:
+		return false
+	}
+}
+
+// handleChannel forwards a channel message from another instance to the hub.
+func (b *NatsBridge) handleChannel(data []byte) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		b.logger.Error().Err(err).Msg("failed to decode nats message")
+		return
+	}
+	// Skip messages that originated from this instance.
+	if env.InstanceID == b.instanceID {
+		return
+	}
+
+	b.logger.Debug().
+		Str("from_instance", env.InstanceID).
+		Str("channel", env.Message.Channel).
+		Msg("relaying message from nats")
+
+	b.hub.BroadcastToLocal(env.Message)
+}
+
+// handleDirect delivers a direct message locally and replies "1" if the
+// client took it, "0" otherwise.
+func (b *NatsBridge) handleDirect(m *nats.Msg) {
+	var env envelope
+	if err := json.Unmarshal(m.Data, &env); err != nil {
+		b.logger.Error().Err(err).Msg("failed to decode nats direct message")
+		return
+	}
+	delivered := false
+	if target, ok := b.hub.(DirectTarget); ok && env.Target != "" {
+		delivered = target.SendToLocal(env.Target, env.Message)
+	}
+	reply := "0"
+	if delivered {
+		reply = "1"
+	}
+	if err := m.Respond([]byte(reply)); err != nil {
+		b.logger.Warn().Err(err).Str("client_id", env.Target).Msg("failed to ack direct message")
+	}
+}
+
+// channelSubject is the subject carrying a hub channel's messages.
+func (b *NatsBridge) channelSubject(channel string) string {
+	return b.cfg.Subject + ".channel." + subjectToken(channel)
+}
+
+// clientSubject is the subject carrying direct messages for a client.
+func (b *NatsBridge) clientSubject(clientID string) string {
+	return b.cfg.Subject + ".client." + subjectToken(clientID)
+}
+
+// subjectToken makes a name safe to use as a single subject token. Names
+// that contain separators, wildcards or whitespace are base64url-encoded
+// behind a "~" marker so they cannot collide with plain names.
+func subjectToken(name string) string {
+	if name != "" && !strings.HasPrefix(name, "~") && !strings.ContainsAny(name, ".*> \t\r\n") {
+		return name
+	}
+	return "~" + base64.RawURLEncoding.EncodeToString([]byte(name))
+}
@@ -0,0 +1,159 @@
+package bridge
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runNatsServer starts an embedded NATS server with JetStream enabled.
+func runNatsServer(t *testing.T) *server.Server {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+	go srv.Start()
+	require.True(t, srv.ReadyForConnections(5*time.Second), "nats server not ready")
+	t.Cleanup(srv.Shutdown)
+	return srv
+}
+
+// recordingTarget is a thread-safe hub stand-in for bridges that deliver
+// on their own goroutines.
+type recordingTarget struct {
+	mu     sync.Mutex
+	msgs   []types.Message
+	local  map[string]bool // clients SendToLocal accepts
+	direct []string
+}
+
+func (r *recordingTarget) BroadcastToLocal(msg types.Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, msg)
+}
+
+func (r *recordingTarget) SendToLocal(clientID string, _ types.Message) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.direct = append(r.direct, clientID)
+	return r.local[clientID]
+}
+
+func (r *recordingTarget) received() []types.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]types.Message(nil), r.msgs...)
+}
+
+func startNatsBridge(t *testing.T, url string, jetStream bool, target BroadcastTarget) *NatsBridge {
+	t.Helper()
+	cfg := DefaultNatsConfig()
+	cfg.URL = url
+	cfg.JetStream = jetStream
+	cfg.ReconnectWait = 20 * time.Millisecond
+
+	nb := NewNatsBridge(cfg, target, testLogger())
+	require.NoError(t, nb.Start())
+	t.Cleanup(func() { _ = nb.Stop() })
+	require.Eventually(t, func() bool { return nb.State() == StateConnected },
+		5*time.Second, 5*time.Millisecond, "nats bridge never connected")
+	return nb
+}
+
+func testNatsRelay(t *testing.T, jetStream bool) {
+	srv := runNatsServer(t)
+	ta, tb := &recordingTarget{}, &recordingTarget{}
+	a := startNatsBridge(t, srv.ClientURL(), jetStream, ta)
+	b := startNatsBridge(t, srv.ClientURL(), jetStream, tb)
+
+	require.NoError(t, a.Watch("news"))
+	require.NoError(t, b.Watch("news"))
+	require.NoError(t, b.Watch("other"))
+	require.NoError(t, a.Publish(types.Message{Channel: "news", Event: "hello"}))
+
+	require.Eventually(t, func() bool { return len(tb.received()) == 1 },
+		2*time.Second, 5*time.Millisecond, "watching instance should receive the message")
+	assert.Equal(t, "hello", tb.received()[0].Event)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, ta.received(), "publisher must skip its own message")
+
+	require.NoError(t, b.Unwatch("news"))
+	require.NoError(t, a.Publish(types.Message{Channel: "news", Event: "ignored"}))
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, tb.received(), 1, "unwatched channel should not be delivered")
+}
+
+func TestNatsBridgeRelaysChannelMessages(t *testing.T) {
+	testNatsRelay(t, false)
+}
+
+func TestNatsBridgeJetStreamRelaysChannelMessages(t *testing.T) {
+	testNatsRelay(t, true)
+}
+
+func TestNatsBridgeSendDirect(t *testing.T) {
+	srv := runNatsServer(t)
+	holder := &recordingTarget{local: map[string]bool{"client-1": true}}
+	a := startNatsBridge(t, srv.ClientURL(), false, holder)
+	b := startNatsBridge(t, srv.ClientURL(), false, &recordingTarget{})
+
+	require.NoError(t, a.Attach("client-1"))
+	require.NoError(t, a.Attach("gone"))
+
+	ok, err := b.SendDirect("client-1", types.Message{Channel: "dm"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = b.SendDirect("gone", types.Message{Channel: "dm"})
+	require.NoError(t, err)
+	assert.False(t, ok, "instance that cannot deliver should answer false")
+
+	ok, err = b.SendDirect("nobody", types.Message{Channel: "dm"})
+	require.NoError(t, err)
+	assert.False(t, ok, "no responder means no instance holds the client")
+
+	require.NoError(t, a.Detach("client-1"))
+	ok, err = b.SendDirect("client-1", types.Message{Channel: "dm"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestNatsBridgeStates(t *testing.T) {
+	cfg := DefaultNatsConfig()
+	cfg.URL = "nats://127.0.0.1:1"
+	cfg.ReconnectBufSize = -1
+	nb := NewNatsBridge(cfg, &recordingTarget{}, testLogger())
+	log := &stateLog{}
+	nb.OnStateChange(log.record)
+
+	require.NoError(t, nb.Start(), "an unreachable server should not fail Start")
+	assert.Equal(t, StateConnecting, nb.State())
+	assert.False(t, nb.Available(), "no reconnect buffer means no publishes while connecting")
+
+	require.NoError(t, nb.Stop())
+	assert.Equal(t, StateStopped, nb.State())
+	assert.Equal(t, []State{StateStopped}, log.all())
+}
+
+func TestSubjectToken(t *testing.T) {
+	assert.Equal(t, "news", subjectToken("news"))
+	assert.Equal(t, "presence-room", subjectToken("presence-room"))
+	for _, name := range []string{"a.b", "*", "team>", "with space", "~x", ""} {
+		token := subjectToken(name)
+		assert.Regexp(t, `^~[A-Za-z0-9_-]*$`, token, "name %q", name)
+	}
+	assert.NotEqual(t, subjectToken("a.b"), subjectToken("a>b"))
+}
@@ -0,0 +1,299 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/rs/zerolog"
+)
+
+// notifyLimit is the size NOTIFY payloads must stay below.
+const notifyLimit = 8000
+
+// pgNotice is a NOTIFY payload: either a full envelope or, for envelopes
+// too large for NOTIFY, a pointer to the spill table row holding it.
+type pgNotice struct {
+	envelope
+	Spill int64 `json:"spill,omitempty"`
+}
+
+// PostgresBridge relays WebSocket messages between server instances with
+// Postgres LISTEN/NOTIFY, for deployments that run Postgres but not Redis.
+//
+// Every instance listens on one NOTIFY channel on a dedicated connection
+// and publishes through a pool. Envelopes of notifyLimit bytes or more are
+// written to a spill table and announced by row ID. A supervisor
+// goroutine reconnects the listener with exponential backoff. Direct
+// messages are not routed: NOTIFY cannot report whether anyone received
+// them.
+type PostgresBridge struct {
+	cfg        PostgresConfig
+	instanceID string
+	hub        BroadcastTarget
+	logger     zerolog.Logger
+	stateTracker
+
+	pool   *pgxpool.Pool
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPostgresBridge creates a bridge that uses Postgres LISTEN/NOTIFY for
+// cross-instance messaging.
+func NewPostgresBridge(cfg *PostgresConfig, hub BroadcastTarget, logger zerolog.Logger) *PostgresBridge {
+	c := *cfg
+	defaults := DefaultPostgresConfig()
+	if c.Channel == "" {
+		c.Channel = defaults.Channel
+	}
+	if c.Table == "" {
+		c.Table = defaults.Table
+	}
+	if c.ReconnectMin <= 0 {
+		c.ReconnectMin = defaults.ReconnectMin
+	}
+	if c.ReconnectMax < c.ReconnectMin {
+		c.ReconnectMax = max(defaults.ReconnectMax, c.ReconnectMin)
+	}
+	if c.SpillTTL <= 0 {
+		c.SpillTTL = defaults.SpillTTL
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	logger = logger.With().Str("component", "postgres-bridge").Logger()
+	return &PostgresBridge{
+		cfg:          c,
+		instanceID:   uuid.New().String(),
+		hub:          hub,
+		logger:       logger,
+		stateTracker: newStateTracker(logger, zerolog.InfoLevel),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Start creates the publish pool and launches the listener supervisor.
+// Only an invalid DSN is an error; an unreachable server is retried in
+// the background.
+func (b *PostgresBridge) Start() error {
+	pool, err := pgxpool.New(b.ctx, b.cfg.DSN)
+	if err != nil {
+		return err
+	}
+	b.pool = pool
+
+	b.wg.Add(2)
+	go b.run()
+	go b.sweepSpill()
+
+	b.logger.Info().
+		Str("instance_id", b.instanceID).
+		Str("channel", b.cfg.Channel).
+		Msg("postgres bridge started")
+	return nil
+}
+
+// Publish notifies the other instances, spilling oversized envelopes.
+func (b *PostgresBridge) Publish(msg types.Message) error {
+	if b.State() != StateConnected {
+		return ErrUnavailable
+	}
+	data, err := json.Marshal(envelope{InstanceID: b.instanceID, Message: msg})
+	if err != nil {
+		return err
+	}
+	if len(data) < notifyLimit {
+		_, err = b.pool.Exec(b.ctx, "SELECT pg_notify($1, $2)", b.cfg.Channel, string(data))
+		return err
+	}
+	return b.publishSpilled(data)
+}
+
+// publishSpilled stores data in the spill table and notifies its row ID.
+// Both happen in one transaction: NOTIFY is delivered on commit, so
+// listeners always find the row.
+func (b *PostgresBridge) publishSpilled(data []byte) error {
+	tx, err := b.pool.Begin(b.ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(b.ctx) }()
+
+	var id int64
+	insert := fmt.Sprintf("INSERT INTO %s (payload) VALUES ($1) RETURNING id", b.table())
+	if err := tx.QueryRow(b.ctx, insert, string(data)).Scan(&id); err != nil {
+		return err
+	}
+	notice, err := json.Marshal(pgNotice{envelope: envelope{InstanceID: b.instanceID}, Spill: id})
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(b.ctx, "SELECT pg_notify($1, $2)", b.cfg.Channel, string(notice)); err != nil {
+		return err
+	}
+	return tx.Commit(b.ctx)
+}
+
+// Stop shuts down the listener and closes the pool.
+func (b *PostgresBridge) Stop() error {
+	b.setState(StateStopped)
+	b.cancel()
+	b.wg.Wait()
+	if b.pool != nil {
+		b.pool.Close()
+	}
+	return nil
+}
+
+// Available reports whether the listener is connected.
+func (b *PostgresBridge) Available() bool {
+	return b.State() == StateConnected
+}
+
+// run connects the listener, relays notifications and reconnects with
+// backoff until the bridge stops.
+func (b *PostgresBridge) run() {
+	defer b.wg.Done()
+
+	delay := b.cfg.ReconnectMin
+	for {
+		conn, err := b.listen()
+		if err != nil {
+			if b.ctx.Err() != nil {
+				return
+			}
+			b.logger.Warn().Err(err).Dur("retry_in", delay).Msg("postgres unavailable")
+			select {
+			case <-time.After(delay):
+			case <-b.ctx.Done():
+				return
+			}
+			delay = min(delay*2, b.cfg.ReconnectMax)
+			continue
+		}
+		delay = b.cfg.ReconnectMin
+		b.setState(StateConnected)
+
+		err = b.receive(conn)
+		_ = conn.Close(context.Background())
+		if b.ctx.Err() != nil {
+			return
+		}
+		b.logger.Warn().Err(err).Msg("postgres listener lost")
+		b.setState(StateDegraded)
+	}
+}
+
+// listen opens the listener connection, ensures the spill table exists
+// and subscribes to the NOTIFY channel.
+func (b *PostgresBridge) listen() (*pgx.Conn, error) {
+	conn, err := pgx.Connect(b.ctx, b.cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	ddl := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id BIGSERIAL PRIMARY KEY,
+		payload TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`, b.table())
+	for _, stmt := range []string{ddl, "LISTEN " + pgx.Identifier{b.cfg.Channel}.Sanitize()} {
+		if _, err := conn.Exec(b.ctx, stmt); err != nil {
+			_ = conn.Close(context.Background())
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// receive relays notifications until the connection fails.
+func (b *PostgresBridge) receive(conn *pgx.Conn) error {
+	for {
+		n, err := conn.WaitForNotification(b.ctx)
+		if err != nil {
+			return err
+		}
+		b.handleNotification(n.Payload)
+	}
+}
+
+// handleNotification decodes a notice, loads spilled envelopes, and
+// forwards non-self messages to the hub.
+func (b *PostgresBridge) handleNotification(payload string) {
+	var notice pgNotice
+	if err := json.Unmarshal([]byte(payload), &notice); err != nil {
+		b.logger.Error().Err(err).Msg("failed to decode postgres notification")
+		return
+	}
+
+	// Skip messages that originated from this instance.
+	if notice.InstanceID == b.instanceID {
+		return
+	}
+
+	env := notice.envelope
+	if notice.Spill != 0 {
+		var err error
+		if env, err = b.loadSpilled(notice.Spill); err != nil {
+			b.logger.Error().Err(err).Int64("spill", notice.Spill).Msg("failed to load spilled message")
+			return
+		}
+	}
+
+	b.logger.Debug().
+		Str("from_instance", env.InstanceID).
+		Str("channel", env.Message.Channel).
+		Msg("relaying message from postgres")
+
+	b.hub.BroadcastToLocal(env.Message)
+}
+
+// loadSpilled reads an envelope from the spill table.
+func (b *PostgresBridge) loadSpilled(id int64) (envelope, error) {
+	var env envelope
+	var payload string
+	query := fmt.Sprintf("SELECT payload FROM %s WHERE id = $1", b.table())
+	if err := b.pool.QueryRow(b.ctx, query, id).Scan(&payload); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return env, fmt.Errorf("spilled message %d expired", id)
+		}
+		return env, err
+	}
+	return env, json.Unmarshal([]byte(payload), &env)
+}
+
+// sweepSpill deletes spilled payloads older than SpillTTL. Every instance
+// sweeps, since no single listener knows when all others have read a row.
+func (b *PostgresBridge) sweepSpill() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.cfg.SpillTTL)
+	defer ticker.Stop()
+	query := fmt.Sprintf("DELETE FROM %s WHERE created_at < now() - make_interval(secs => $1)", b.table())
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if b.State() != StateConnected {
+			continue
+		}
+		if _, err := b.pool.Exec(b.ctx, query, b.cfg.SpillTTL.Seconds()); err != nil {
+			b.logger.Warn().Err(err).Msg("failed to sweep spilled messages")
+		}
+	}
+}
+
+// table is the quoted spill table name.
+func (b *PostgresBridge) table() string {
+	return pgx.Identifier{b.cfg.Table}.Sanitize()
+}
@@ -0,0 +1,84 @@
+package bridge
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPgNoticeInlinesEnvelope(t *testing.T) {
+	data, err := json.Marshal(pgNotice{envelope: envelope{
+		InstanceID: "node-1",
+		Message:    types.Message{Channel: "news", Event: "hi"},
+	}})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "spill", "small notices carry no spill pointer")
+
+	var decoded pgNotice
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "node-1", decoded.InstanceID)
+	assert.Equal(t, "news", decoded.Message.Channel)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"instance_id":"node-2","spill":42}`), &decoded))
+	assert.Equal(t, int64(42), decoded.Spill)
+}
+
+func TestPostgresBridgeHandleNotification(t *testing.T) {
+	target := &recordingTarget{}
+	pb := NewPostgresBridge(DefaultPostgresConfig(), target, testLogger())
+
+	own, _ := json.Marshal(pgNotice{envelope: envelope{InstanceID: pb.instanceID, Message: types.Message{Channel: "a"}}})
+	other, _ := json.Marshal(pgNotice{envelope: envelope{InstanceID: "other", Message: types.Message{Channel: "b"}}})
+	pb.handleNotification(string(own))
+	pb.handleNotification(string(other))
+	pb.handleNotification("not json")
+
+	got := target.received()
+	require.Len(t, got, 1, "own and malformed notifications should be skipped")
+	assert.Equal(t, "b", got[0].Channel)
+}
+
+func TestPostgresBridgeUnavailableBeforeConnect(t *testing.T) {
+	pb := NewPostgresBridge(DefaultPostgresConfig(), &recordingTarget{}, testLogger())
+	assert.False(t, pb.Available())
+	assert.ErrorIs(t, pb.Publish(types.Message{Channel: "news"}), ErrUnavailable)
+}
+
+// TestPostgresBridgeRelay runs against a real server when
+// POSTGRES_TEST_DSN is set, e.g. postgres://postgres@localhost/postgres.
+func TestPostgresBridgeRelay(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+	start := func(target BroadcastTarget) *PostgresBridge {
+		cfg := DefaultPostgresConfig()
+		cfg.DSN = dsn
+		cfg.Channel = "orchestra_ws_test"
+		cfg.Table = "orchestra_ws_spill_test"
+		pb := NewPostgresBridge(cfg, target, testLogger())
+		require.NoError(t, pb.Start())
+		t.Cleanup(func() { _ = pb.Stop() })
+		require.Eventually(t, pb.Available, 5*time.Second, 10*time.Millisecond)
+		return pb
+	}
+	ta, tb := &recordingTarget{}, &recordingTarget{}
+	a := start(ta)
+	_ = start(tb)
+
+	big := strings.Repeat("x", 2*notifyLimit)
+	require.NoError(t, a.Publish(types.Message{Channel: "news", Event: "small"}))
+	require.NoError(t, a.Publish(types.Message{Channel: "news", Event: "big", Data: map[string]any{"blob": big}}))
+
+	require.Eventually(t, func() bool { return len(tb.received()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	got := tb.received()
+	assert.Equal(t, "small", got[0].Event)
+	assert.Equal(t, big, got[1].Data["blob"], "oversized message should arrive through the spill table")
+	assert.Empty(t, ta.received(), "publisher must skip its own messages")
+}
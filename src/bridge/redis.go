@@ -3,7 +3,10 @@ package bridge
 import (
 	"context"
 	"encoding/json"
+	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/orchestra-mcp/socket/src/types"
@@ -11,79 +14,153 @@ import (
 	"github.com/rs/zerolog"
 )
 
-// redisEnvelope wraps a message with the originating instance ID
-// so that a node can skip its own published messages.
-type redisEnvelope struct {
-	InstanceID string        `json:"instance_id"`
-	Message    types.Message `json:"message"`
+// outbound is a publish held back while Redis is unreachable.
+type outbound struct {
+	channel string
+	data    []byte
 }
 
 // RedisBridge relays WebSocket messages between server instances via Redis pub/sub.
+//
+// A supervisor goroutine connects with exponential backoff, pings Redis
+// while connected, and on failure moves to StateDegraded, reconnects and
+// resubscribes to every channel the hub is interested in. On a Redis
+// Cluster it publishes and subscribes with sharded pub/sub. In streams
+// mode channel messages go through a Redis stream instead, so an instance
+// catches up on what it missed while disconnected.
 type RedisBridge struct {
-	client     *redis.Client
+	cfg        RedisConfig
+	client     redis.UniversalClient // set by Start
+	cluster    bool
 	prefix     string
 	instanceID string
+	address    string
+	startedAt  time.Time
 	hub        BroadcastTarget
 	logger     zerolog.Logger
+	stateTracker
+
+	reconnectMin   time.Duration
+	reconnectMax   time.Duration
+	healthInterval time.Duration
+	statsInterval  time.Duration
+	bufferSize     int
+
+	streamKey    string // set in streams mode
+	posKey       string // this instance's saved stream position
+	streamMaxLen int64
+	streamMaxAge time.Duration
 
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
-	mu     sync.RWMutex
-	active bool
+	lost   chan struct{} // a publish failed; check the connection now
+
+	subMu    sync.Mutex          // serializes subscription changes
+	interest map[string]struct{} // Redis channels to (re)subscribe
+
+	mu      sync.RWMutex
+	sub     subscription
+	outbox  []outbound
+	dropped int
+
+	lastID     string             // last stream entry relayed
+	stopStream context.CancelFunc // ends the current stream reader
+	onNodeDown []func(types.NodeDown)
+
+	dirMu   sync.Mutex           // serializes directory changes
+	clients map[string]struct{}  // local clients, mirrored in the directory set
+	members map[memberKey]string // local presence members, encoded as listed
+	listed  bool                 // the directory sets reflect clients and members
 }
 
 // NewRedisBridge creates a bridge that uses Redis pub/sub for cross-instance messaging.
 func NewRedisBridge(cfg *RedisConfig, hub BroadcastTarget, logger zerolog.Logger) *RedisBridge {
-	client := redis.NewClient(&redis.Options{
-		Addr:     cfg.Addr,
-		Password: cfg.Password,
-		DB:       cfg.DB,
-	})
+	logger = logger.With().Str("component", "redis-bridge").Logger()
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &RedisBridge{
-		client:     client,
-		prefix:     cfg.Prefix,
-		instanceID: uuid.New().String(),
-		hub:        hub,
-		logger:     logger.With().Str("component", "redis-bridge").Logger(),
-		ctx:        ctx,
-		cancel:     cancel,
+	defaults := DefaultRedisConfig()
+	b := &RedisBridge{
+		cfg:            *cfg,
+		cluster:        cfg.Cluster,
+		prefix:         cfg.Prefix,
+		instanceID:     uuid.New().String(),
+		address:        cfg.NodeAddress,
+		startedAt:      time.Now(),
+		hub:            hub,
+		logger:         logger,
+		stateTracker:   newStateTracker(logger, zerolog.InfoLevel),
+		reconnectMin:   cfg.ReconnectMin,
+		reconnectMax:   cfg.ReconnectMax,
+		healthInterval: cfg.HealthInterval,
+		statsInterval:  cfg.StatsInterval,
+		bufferSize:     cfg.BufferSize,
+		ctx:            ctx,
+		cancel:         cancel,
+		lost:           make(chan struct{}, 1),
+		interest:       make(map[string]struct{}),
+		clients:        make(map[string]struct{}),
+		members:        make(map[memberKey]string),
+	}
+	if b.reconnectMin <= 0 {
+		b.reconnectMin = defaults.ReconnectMin
+	}
+	if b.reconnectMax < b.reconnectMin {
+		b.reconnectMax = max(defaults.ReconnectMax, b.reconnectMin)
+	}
+	if b.healthInterval <= 0 {
+		b.healthInterval = defaults.HealthInterval
+	}
+	if b.statsInterval <= 0 {
+		b.statsInterval = defaults.StatsInterval
+	}
+	if b.address == "" {
+		b.address, _ = os.Hostname()
 	}
+	if cfg.Streams {
+		consumer := cfg.StreamConsumer
+		if consumer == "" {
+			consumer = b.instanceID
+		}
+		b.streamKey = cfg.Prefix + "stream"
+		b.posKey = cfg.Prefix + "stream:pos:" + consumer
+		b.streamMaxLen = cfg.StreamMaxLen
+		b.streamMaxAge = cfg.StreamMaxAge
+	}
+	return b
 }
 
-// Start subscribes to the Redis broadcast channel and begins relaying messages.
+// Start launches the supervisor, which connects to Redis in the background
+// and keeps retrying while it is unreachable, so Start does not fail when
+// Redis is down; only invalid settings, such as unreadable TLS files, are
+// an error. Subscriptions are added through Watch and Attach as local
+// interest appears.
 func (b *RedisBridge) Start() error {
-	if err := b.client.Ping(b.ctx).Err(); err != nil {
+	client, err := newRedisClient(&b.cfg)
+	if err != nil {
 		return err
 	}
+	b.client = client
 
-	channel := b.prefix + "broadcast"
-	sub := b.client.Subscribe(b.ctx, channel)
-
-	// Wait for subscription confirmation.
-	if _, err := sub.Receive(b.ctx); err != nil {
-		return err
+	b.wg.Add(2)
+	go b.run()
+	go b.reportStats()
+	if b.streamKey != "" && b.streamMaxAge > 0 {
+		b.wg.Add(1)
+		go b.trimStream()
 	}
 
-	b.mu.Lock()
-	b.active = true
-	b.mu.Unlock()
-
-	b.wg.Add(1)
-	go b.listen(sub)
-
 	b.logger.Info().
 		Str("instance_id", b.instanceID).
-		Str("channel", channel).
+		Str("prefix", b.prefix).
 		Msg("redis bridge started")
 	return nil
 }
 
-// Publish sends a message to all other instances via Redis.
+// Publish sends a message to the instances watching its channel, or
+// appends it to the stream in streams mode.
 func (b *RedisBridge) Publish(msg types.Message) error {
-	env := redisEnvelope{
+	env := envelope{
 		InstanceID: b.instanceID,
 		Message:    msg,
 	}
@@ -91,30 +168,403 @@ func (b *RedisBridge) Publish(msg types.Message) error {
 	if err != nil {
 		return err
 	}
-	channel := b.prefix + "broadcast"
-	return b.client.Publish(b.ctx, channel, data).Err()
+	if b.streamKey != "" {
+		return b.publish(b.streamKey, data)
+	}
+	return b.publish(b.channelKey(msg.Channel), data)
 }
 
-// Stop unsubscribes and closes the Redis connection.
-func (b *RedisBridge) Stop() error {
+// publish sends data while connected. During an outage, or when the send
+// fails, it is held in the outbox if buffering is enabled.
+func (b *RedisBridge) publish(channel string, data []byte) error {
 	b.mu.Lock()
-	b.active = false
+	if b.State() != StateConnected {
+		defer b.mu.Unlock()
+		return b.bufferLocked(channel, data)
+	}
 	b.mu.Unlock()
 
+	err := b.write(b.ctx, channel, data)
+	if err == nil {
+		return nil
+	}
+	b.signalLost()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.bufferLocked(channel, data) == nil {
+		return nil
+	}
+	return err
+}
+
+// bufferLocked appends to the outbox, dropping the oldest entry when it is
+// full. The caller holds mu.
+func (b *RedisBridge) bufferLocked(channel string, data []byte) error {
+	if b.bufferSize <= 0 || b.State() == StateStopped {
+		return ErrUnavailable
+	}
+	if len(b.outbox) >= b.bufferSize {
+		b.outbox = b.outbox[1:]
+		b.dropped++
+	}
+	b.outbox = append(b.outbox, outbound{channel: channel, data: data})
+	return nil
+}
+
+// write sends an outbound message: appended to the stream if it targets
+// the stream key, published otherwise.
+func (b *RedisBridge) write(ctx context.Context, channel string, data []byte) error {
+	if channel == b.streamKey {
+		return b.appendStream(ctx, data)
+	}
+	return b.send(ctx, channel, data).Err()
+}
+
+// send publishes data, sharded on a cluster.
+func (b *RedisBridge) send(ctx context.Context, channel string, data []byte) *redis.IntCmd {
+	if b.cluster {
+		return b.client.SPublish(ctx, channel, data)
+	}
+	return b.client.Publish(ctx, channel, data)
+}
+
+// channelKey is the Redis channel carrying a hub channel's messages.
+func (b *RedisBridge) channelKey(channel string) string {
+	return b.prefix + "channel:" + channel
+}
+
+// Watch subscribes to a hub channel's Redis channel. In streams mode
+// every instance reads the whole stream, so it does nothing.
+func (b *RedisBridge) Watch(channel string) error {
+	if b.streamKey != "" {
+		return nil
+	}
+	return b.subscribe(b.channelKey(channel))
+}
+
+// Unwatch unsubscribes from a hub channel's Redis channel.
+func (b *RedisBridge) Unwatch(channel string) error {
+	if b.streamKey != "" {
+		return nil
+	}
+	return b.unsubscribe(b.channelKey(channel))
+}
+
+// clientChannel is the Redis channel carrying direct messages for a client.
+func (b *RedisBridge) clientChannel(clientID string) string {
+	return b.prefix + "client:" + clientID
+}
+
+// Attach subscribes to the direct-message channel of a local client and
+// lists it in this instance's directory set.
+func (b *RedisBridge) Attach(clientID string) error {
+	if err := b.subscribe(b.clientChannel(clientID)); err != nil {
+		return err
+	}
+	return b.listClient(clientID, true)
+}
+
+// Detach unsubscribes from a client's direct-message channel and removes
+// it from the directory.
+func (b *RedisBridge) Detach(clientID string) error {
+	if err := b.unsubscribe(b.clientChannel(clientID)); err != nil {
+		return err
+	}
+	return b.listClient(clientID, false)
+}
+
+// subscribe records interest in a Redis channel and subscribes to it if
+// connected. While disconnected, the next connection picks it up.
+func (b *RedisBridge) subscribe(channel string) error {
+	b.subMu.Lock()
+	defer b.subMu.Unlock()
+	b.interest[channel] = struct{}{}
+
+	sub := b.currentSub()
+	if sub == nil {
+		return nil
+	}
+	if err := sub.Subscribe(b.ctx, channel); err != nil {
+		b.signalLost()
+		return err
+	}
+	return nil
+}
+
+// unsubscribe drops interest in a Redis channel.
+func (b *RedisBridge) unsubscribe(channel string) error {
+	b.subMu.Lock()
+	defer b.subMu.Unlock()
+	delete(b.interest, channel)
+
+	sub := b.currentSub()
+	if sub == nil {
+		return nil
+	}
+	if err := sub.Unsubscribe(b.ctx, channel); err != nil {
+		b.signalLost()
+		return err
+	}
+	return nil
+}
+
+// currentSub returns the live subscription, or nil while disconnected.
+func (b *RedisBridge) currentSub() subscription {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.sub
+}
+
+// SendDirect publishes a message to the instance holding clientID. It
+// reports false when no instance is subscribed for that client. Direct
+// messages are never buffered: delivery depends on a live receiver count.
+func (b *RedisBridge) SendDirect(clientID string, msg types.Message) (bool, error) {
+	return b.sendDirect(envelope{
+		InstanceID: b.instanceID,
+		Target:     clientID,
+		Message:    msg,
+	})
+}
+
+// sendDirect publishes env on its target's direct-message channel.
+func (b *RedisBridge) sendDirect(env envelope) (bool, error) {
+	if b.State() != StateConnected {
+		return false, ErrUnavailable
+	}
+	data, err := json.Marshal(env)
+	if err != nil {
+		return false, err
+	}
+	n, err := b.send(b.ctx, b.clientChannel(env.Target), data).Result()
+	if err != nil {
+		b.signalLost()
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Stop shuts down the supervisor and closes the Redis connection.
+// Buffered publishes that were never sent are discarded.
+func (b *RedisBridge) Stop() error {
+	wasConnected := b.State() == StateConnected
+	b.setState(StateStopped)
+
 	b.cancel()
 	b.wg.Wait()
+
+	b.dirMu.Lock()
+	b.listed = false
+	b.dirMu.Unlock()
+
+	b.mu.Lock()
+	pending := len(b.outbox)
+	b.outbox = nil
+	b.mu.Unlock()
+	if pending > 0 {
+		b.logger.Warn().Int("pending", pending).Msg("discarding buffered publishes on stop")
+	}
+	if b.client == nil {
+		return nil
+	}
+	if wasConnected {
+		b.deregister()
+	}
 	return b.client.Close()
 }
 
-// Available reports whether the bridge is connected.
+// Available reports whether the bridge accepts publishes: it is connected,
+// or it is reconnecting with outbound buffering enabled.
 func (b *RedisBridge) Available() bool {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
-	return b.active
+	switch b.State() {
+	case StateConnected:
+		return true
+	case StateConnecting, StateDegraded:
+		return b.bufferSize > 0
+	default:
+		return false
+	}
+}
+
+// signalLost asks the supervisor to check the connection without waiting
+// for the next health-check tick.
+func (b *RedisBridge) signalLost() {
+	select {
+	case b.lost <- struct{}{}:
+	default:
+	}
+}
+
+// run connects, monitors and reconnects until the bridge stops.
+func (b *RedisBridge) run() {
+	defer b.wg.Done()
+
+	delay := b.reconnectMin
+	for {
+		if err := b.connect(); err != nil {
+			b.logger.Warn().Err(err).Dur("retry_in", delay).Msg("redis unavailable")
+			select {
+			case <-time.After(delay):
+			case <-b.ctx.Done():
+				return
+			}
+			delay = min(delay*2, b.reconnectMax)
+			continue
+		}
+		delay = b.reconnectMin
+
+		b.monitor()
+		if b.ctx.Err() != nil {
+			return
+		}
+		b.setState(StateDegraded)
+		b.disconnect()
+	}
+}
+
+// connect pings Redis, resubscribes to every channel of interest, resumes
+// the stream, registers the node and its directory, flushes the outbox,
+// and moves to StateConnected.
+func (b *RedisBridge) connect() error {
+	if err := b.client.Ping(b.ctx).Err(); err != nil {
+		return err
+	}
+	if b.streamKey != "" {
+		if err := b.resumeStream(); err != nil {
+			return err
+		}
+	}
+
+	b.subMu.Lock()
+	channels := make([]string, 0, len(b.interest))
+	for ch := range b.interest {
+		channels = append(channels, ch)
+	}
+	sub := b.newSubscription()
+	if len(channels) > 0 {
+		if err := sub.Subscribe(b.ctx, channels...); err != nil {
+			b.subMu.Unlock()
+			_ = sub.Close()
+			return err
+		}
+	}
+	b.mu.Lock()
+	b.sub = sub
+	b.mu.Unlock()
+	b.subMu.Unlock()
+
+	b.wg.Add(1)
+	go b.listen(sub)
+	if b.streamKey != "" {
+		ctx, cancel := context.WithCancel(b.ctx)
+		b.mu.Lock()
+		b.stopStream = cancel
+		b.mu.Unlock()
+		b.wg.Add(1)
+		go b.readStream(ctx)
+	}
+
+	// Register before reporting connected, so that a connected bridge is
+	// always visible to NodeStats, Locate and other instances' reapers.
+	b.heartbeat(true)
+	if err := b.flush(); err != nil {
+		b.disconnect()
+		return err
+	}
+	return nil
+}
+
+// newSubscription opens an empty subscription: sharded on a cluster,
+// a single PubSub otherwise.
+func (b *RedisBridge) newSubscription() subscription {
+	if cc, ok := b.client.(*redis.ClusterClient); ok {
+		return newShardedSub(cc)
+	}
+	return b.client.Subscribe(b.ctx)
+}
+
+// flush publishes buffered messages in order and switches to
+// StateConnected once the outbox is empty, so new publishes cannot
+// overtake buffered ones.
+func (b *RedisBridge) flush() error {
+	for {
+		b.mu.Lock()
+		if len(b.outbox) == 0 {
+			dropped := b.dropped
+			b.dropped = 0
+			b.mu.Unlock()
+			if dropped > 0 {
+				b.logger.Warn().Int("dropped", dropped).Msg("outbound buffer overflowed during outage")
+			}
+			b.setState(StateConnected)
+			return nil
+		}
+		pending := b.outbox
+		b.outbox = nil
+		b.mu.Unlock()
+
+		for i, out := range pending {
+			if err := b.write(b.ctx, out.channel, out.data); err != nil {
+				b.mu.Lock()
+				b.outbox = append(pending[i:], b.outbox...)
+				if over := len(b.outbox) - b.bufferSize; over > 0 {
+					b.outbox = b.outbox[over:]
+					b.dropped += over
+				}
+				b.mu.Unlock()
+				return err
+			}
+		}
+	}
+}
+
+// monitor pings Redis until a check fails or the bridge stops.
+func (b *RedisBridge) monitor() {
+	ticker := time.NewTicker(b.healthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-b.lost:
+		}
+		if err := b.client.Ping(b.ctx).Err(); err != nil {
+			if b.ctx.Err() == nil {
+				b.logger.Warn().Err(err).Msg("redis connection lost")
+			}
+			return
+		}
+		// A failed publish may have been buffered while Redis was fine.
+		_ = b.flush()
+	}
+}
+
+// disconnect closes the current subscription and stream reader; their
+// goroutines exit.
+func (b *RedisBridge) disconnect() {
+	b.subMu.Lock()
+	defer b.subMu.Unlock()
+	b.mu.Lock()
+	sub := b.sub
+	b.sub = nil
+	if b.stopStream != nil {
+		b.stopStream()
+		b.stopStream = nil
+	}
+	b.mu.Unlock()
+	if sub != nil {
+		_ = sub.Close()
+	}
+
+	b.dirMu.Lock()
+	b.listed = false
+	b.dirMu.Unlock()
 }
 
 // listen reads messages from the Redis subscription and forwards to the local hub.
-func (b *RedisBridge) listen(sub *redis.PubSub) {
+func (b *RedisBridge) listen(sub subscription) {
 	defer b.wg.Done()
 	defer sub.Close()
 
@@ -134,12 +584,21 @@ func (b *RedisBridge) listen(sub *redis.PubSub) {
 
 // handleRedisMessage decodes an envelope and forwards non-self messages to the hub.
 func (b *RedisBridge) handleRedisMessage(msg *redis.Message) {
-	var env redisEnvelope
+	var env envelope
 	if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
 		b.logger.Error().Err(err).Msg("failed to decode redis message")
 		return
 	}
 
+	if strings.HasPrefix(msg.Channel, b.prefix+"client:") {
+		b.deliverDirect(env)
+		return
+	}
+	b.relay(env)
+}
+
+// relay forwards a channel message to the hub unless this instance sent it.
+func (b *RedisBridge) relay(env envelope) {
 	// Skip messages that originated from this instance.
 	if env.InstanceID == b.instanceID {
 		return
@@ -152,3 +611,29 @@ func (b *RedisBridge) handleRedisMessage(msg *redis.Message) {
 
 	b.hub.BroadcastToLocal(env.Message)
 }
+
+// deliverDirect hands a direct message or kick to the local hub.
+func (b *RedisBridge) deliverDirect(env envelope) {
+	if env.Target == "" {
+		return
+	}
+	if env.Op == opKick {
+		if kt, ok := b.hub.(KickTarget); ok && kt.KickLocal(env.Target) {
+			b.logger.Info().
+				Str("client_id", env.Target).
+				Str("from_instance", env.InstanceID).
+				Msg("client kicked")
+		}
+		return
+	}
+	target, ok := b.hub.(DirectTarget)
+	if !ok {
+		return
+	}
+	if !target.SendToLocal(env.Target, env.Message) {
+		b.logger.Warn().
+			Str("client_id", env.Target).
+			Str("from_instance", env.InstanceID).
+			Msg("direct message not delivered locally")
+	}
+}
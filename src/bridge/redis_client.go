@@ -0,0 +1,210 @@
+package bridge
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// newRedisClient builds a single-node, Sentinel or Cluster client from cfg.
+func newRedisClient(cfg *RedisConfig) (redis.UniversalClient, error) {
+	if cfg.Cluster && cfg.MasterName != "" {
+		return nil, errors.New("redis: cluster and sentinel master name are mutually exclusive")
+	}
+	if cfg.Cluster && cfg.DB != 0 {
+		return nil, errors.New("redis: cluster mode only supports db 0")
+	}
+	tlsConfig, err := cfg.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := cfg.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{cfg.Addr}
+	}
+	opts := &redis.UniversalOptions{
+		Addrs:            addrs,
+		Username:         cfg.Username,
+		Password:         cfg.Password,
+		DB:               cfg.DB,
+		MasterName:       cfg.MasterName,
+		SentinelUsername: cfg.SentinelUsername,
+		SentinelPassword: cfg.SentinelPassword,
+		TLSConfig:        tlsConfig,
+		// The supervisor handles failures; retries only delay detection.
+		MaxRetries: -1,
+	}
+
+	switch {
+	case cfg.Cluster:
+		return redis.NewClusterClient(opts.Cluster()), nil
+	case cfg.MasterName != "":
+		return redis.NewFailoverClient(opts.Failover()), nil
+	default:
+		opts.Addrs = addrs[:1]
+		return redis.NewClient(opts.Simple()), nil
+	}
+}
+
+// tlsConfig loads the TLS settings, or returns nil when TLS is off.
+func (c *RedisConfig) tlsConfig() (*tls.Config, error) {
+	if !c.TLS {
+		return nil, nil
+	}
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.TLSServerName,
+		InsecureSkipVerify: c.TLSInsecureSkipVerify,
+	}
+	if c.TLSCAFile != "" {
+		pem, err := os.ReadFile(c.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("redis tls: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("redis tls: no certificates in %s", c.TLSCAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if c.TLSCertFile != "" || c.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("redis tls: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// subscription is the bridge's set of Redis subscriptions, read as one
+// message stream.
+type subscription interface {
+	Subscribe(ctx context.Context, channels ...string) error
+	Unsubscribe(ctx context.Context, channels ...string) error
+	Channel(opts ...redis.ChannelOption) <-chan *redis.Message
+	Close() error
+}
+
+// shardedSub subscribes with SSUBSCRIBE on a Redis Cluster. A sharded
+// subscription connection serves only the slots of one master, so channels
+// are grouped into one PubSub per master and their messages merged.
+// Channels whose slot migrates are regrouped on the next reconnect.
+type shardedSub struct {
+	client *redis.ClusterClient
+	out    chan *redis.Message
+	done   chan struct{}
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	shards map[string]*redis.PubSub // by master address
+	owner  map[string]string        // channel -> master address
+	closed bool
+}
+
+func newShardedSub(client *redis.ClusterClient) *shardedSub {
+	return &shardedSub{
+		client: client,
+		out:    make(chan *redis.Message, 100),
+		done:   make(chan struct{}),
+		shards: make(map[string]*redis.PubSub),
+		owner:  make(map[string]string),
+	}
+}
+
+// Subscribe adds each channel to the PubSub of the master owning its slot.
+func (s *shardedSub) Subscribe(ctx context.Context, channels ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return redis.ErrClosed
+	}
+	for _, ch := range channels {
+		if _, ok := s.owner[ch]; ok {
+			continue
+		}
+		master, err := s.client.MasterForKey(ctx, ch)
+		if err != nil {
+			return err
+		}
+		addr := master.Options().Addr
+		if ps, ok := s.shards[addr]; ok {
+			if err := ps.SSubscribe(ctx, ch); err != nil {
+				return err
+			}
+		} else {
+			// The first channel picks the node, so subscribe before
+			// reading: Channel() on an empty PubSub dials a random one.
+			ps = s.client.SSubscribe(ctx)
+			if err := ps.SSubscribe(ctx, ch); err != nil {
+				_ = ps.Close()
+				return err
+			}
+			s.shards[addr] = ps
+			s.wg.Add(1)
+			go s.forward(ps)
+		}
+		s.owner[ch] = addr
+	}
+	return nil
+}
+
+// Unsubscribe removes channels from their shard's PubSub.
+func (s *shardedSub) Unsubscribe(ctx context.Context, channels ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ch := range channels {
+		addr, ok := s.owner[ch]
+		if !ok {
+			continue
+		}
+		delete(s.owner, ch)
+		if err := s.shards[addr].SUnsubscribe(ctx, ch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Channel returns the merged message stream. It is closed by Close.
+func (s *shardedSub) Channel(...redis.ChannelOption) <-chan *redis.Message {
+	return s.out
+}
+
+// Close closes every shard's PubSub.
+func (s *shardedSub) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	for _, ps := range s.shards {
+		_ = ps.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	close(s.out)
+	return nil
+}
+
+// forward copies one shard's messages to the merged stream.
+func (s *shardedSub) forward(ps *redis.PubSub) {
+	defer s.wg.Done()
+	for msg := range ps.Channel() {
+		select {
+		case s.out <- msg:
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package bridge
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisConfigFromEnvTopology(t *testing.T) {
+	t.Setenv("REDIS_USERNAME", "ws")
+	t.Setenv("REDIS_ADDRS", "s1:26379, s2:26379,")
+	t.Setenv("REDIS_MASTER_NAME", "mymaster")
+	t.Setenv("REDIS_SENTINEL_PASSWORD", "sentinel-secret")
+	t.Setenv("REDIS_TLS", "true")
+	t.Setenv("REDIS_TLS_CA_FILE", "/etc/ca.pem")
+	t.Setenv("REDIS_TLS_SERVER_NAME", "redis.internal")
+
+	cfg := RedisConfigFromEnv()
+	assert.Equal(t, "ws", cfg.Username)
+	assert.Equal(t, []string{"s1:26379", "s2:26379"}, cfg.Addrs)
+	assert.Equal(t, "mymaster", cfg.MasterName)
+	assert.Equal(t, "sentinel-secret", cfg.SentinelPassword)
+	assert.False(t, cfg.Cluster)
+	assert.True(t, cfg.TLS)
+	assert.Equal(t, "/etc/ca.pem", cfg.TLSCAFile)
+	assert.Equal(t, "redis.internal", cfg.TLSServerName)
+}
+
+func TestNewRedisClientModes(t *testing.T) {
+	single := DefaultRedisConfig()
+	single.Username = "ws"
+	c, err := newRedisClient(single)
+	require.NoError(t, err)
+	require.IsType(t, &redis.Client{}, c)
+	assert.Equal(t, "ws", c.(*redis.Client).Options().Username)
+	_ = c.Close()
+
+	sentinel := DefaultRedisConfig()
+	sentinel.Addrs = []string{"127.0.0.1:1"}
+	sentinel.MasterName = "mymaster"
+	c, err = newRedisClient(sentinel)
+	require.NoError(t, err)
+	assert.IsType(t, &redis.Client{}, c)
+	_ = c.Close()
+
+	cluster := DefaultRedisConfig()
+	cluster.Addrs = []string{"127.0.0.1:1", "127.0.0.1:2"}
+	cluster.Cluster = true
+	c, err = newRedisClient(cluster)
+	require.NoError(t, err)
+	assert.IsType(t, &redis.ClusterClient{}, c)
+	_ = c.Close()
+
+	cluster.DB = 1
+	_, err = newRedisClient(cluster)
+	assert.Error(t, err, "cluster mode has no databases")
+
+	cluster.DB = 0
+	cluster.MasterName = "mymaster"
+	_, err = newRedisClient(cluster)
+	assert.Error(t, err, "cluster and sentinel cannot be combined")
+}
+
+// writeTestCert writes a self-signed certificate and key as PEM files.
+func writeTestCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "redis.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+		KeyUsage:     x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		DNSNames:     []string{"redis.test"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestRedisTLSConfig(t *testing.T) {
+	cfg := DefaultRedisConfig()
+	tc, err := cfg.tlsConfig()
+	require.NoError(t, err)
+	assert.Nil(t, tc, "TLS is off by default")
+
+	certFile, keyFile := writeTestCert(t)
+	cfg.TLS = true
+	cfg.TLSCAFile = certFile
+	cfg.TLSCertFile = certFile
+	cfg.TLSKeyFile = keyFile
+	cfg.TLSServerName = "redis.test"
+	tc, err = cfg.tlsConfig()
+	require.NoError(t, err)
+	assert.NotNil(t, tc.RootCAs)
+	assert.Len(t, tc.Certificates, 1)
+	assert.Equal(t, "redis.test", tc.ServerName)
+
+	cfg.TLSCAFile = keyFile
+	_, err = cfg.tlsConfig()
+	assert.ErrorContains(t, err, "no certificates")
+
+	cfg.TLSCAFile = filepath.Join(t.TempDir(), "missing.pem")
+	_, err = cfg.tlsConfig()
+	assert.Error(t, err)
+}
+
+func TestRedisBridgeStartRejectsBadTLS(t *testing.T) {
+	cfg := DefaultRedisConfig()
+	cfg.TLS = true
+	cfg.TLSCAFile = filepath.Join(t.TempDir(), "missing.pem")
+	rb := NewRedisBridge(cfg, &mockBroadcastTarget{}, testLogger())
+	assert.Error(t, rb.Start())
+	assert.NoError(t, rb.Stop())
+}
+
+// clusterTarget records relayed messages and reports fixed counts.
+type clusterTarget struct {
+	recordingTarget
+}
+
+func (c *clusterTarget) ClientCount() int         { return 1 }
+func (c *clusterTarget) Channels() map[string]int { return map[string]int{} }
+
+func startClusterBridge(t *testing.T, nodes []*fakeRedis, target BroadcastTarget) *RedisBridge {
+	t.Helper()
+	rb, _ := testBridgeFor(t, "", 0, target, func(cfg *RedisConfig) {
+		for _, n := range nodes {
+			cfg.Addrs = append(cfg.Addrs, n.addr)
+		}
+		cfg.Cluster = true
+	})
+	return rb
+}
+
+func TestRedisBridgeClusterShardedPubSub(t *testing.T) {
+	nodes := []*fakeRedis{newFakeRedis(t), newFakeRedis(t)}
+	clusterSlots(nodes...)
+	ta, tb := &clusterTarget{}, &clusterTarget{}
+	a := startClusterBridge(t, nodes, ta)
+	b := startClusterBridge(t, nodes, tb)
+
+	var channels []string
+	for i := range 8 {
+		ch := "room-" + strconv.Itoa(i)
+		channels = append(channels, ch)
+		require.NoError(t, a.Watch(ch))
+	}
+	waitForState(t, a, StateConnected)
+	waitForState(t, b, StateConnected)
+
+	used := map[int]bool{}
+	for _, ch := range channels {
+		key := a.channelKey(ch)
+		require.Eventually(t, func() bool { return nodes[0].subscribed(key) || nodes[1].subscribed(key) },
+			time.Second, 5*time.Millisecond, "%s should be subscribed", key)
+		for i, n := range nodes {
+			if n.subscribed(key) {
+				used[i] = true
+			}
+		}
+		assert.False(t, nodes[0].subscribed(key) && nodes[1].subscribed(key), "%s belongs to one shard", key)
+	}
+	assert.Len(t, used, 2, "channels should spread over both shards")
+
+	for _, ch := range channels {
+		require.NoError(t, b.Publish(types.Message{Channel: ch}))
+	}
+	require.Eventually(t, func() bool { return len(ta.received()) == len(channels) },
+		time.Second, 5*time.Millisecond, "every shard should relay its channels")
+
+	stats, err := a.NodeStats()
+	require.NoError(t, err)
+	assert.Len(t, stats, 2, "stats keys should be read from every master")
+}
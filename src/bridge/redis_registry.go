@@ -0,0 +1,301 @@
+package bridge
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/redis/go-redis/v9"
+)
+
+// clientsKey is the Redis set listing the clients an instance holds.
+func (b *RedisBridge) clientsKey(instanceID string) string {
+	return b.prefix + "clients:" + instanceID
+}
+
+// membersKey is the Redis set listing the presence members an instance
+// holds, each encoded as a listedMember.
+func (b *RedisBridge) membersKey(instanceID string) string {
+	return b.prefix + "members:" + instanceID
+}
+
+// memberKey identifies a local presence member.
+type memberKey struct{ channel, clientID string }
+
+// listedMember is a presence member as stored in the members set.
+type listedMember struct {
+	Channel string       `json:"channel"`
+	Member  types.Member `json:"member"`
+}
+
+// reapKey claims the cleanup of a dead instance for one survivor.
+func (b *RedisBridge) reapKey(instanceID string) string {
+	return b.prefix + "reap:" + instanceID
+}
+
+// NodeID returns the ID this instance registers under.
+func (b *RedisBridge) NodeID() string { return b.instanceID }
+
+// listClient adds or removes a local client in the directory. While the
+// set is not in sync, only the local view changes; the next heartbeat
+// after reconnecting rewrites the set.
+func (b *RedisBridge) listClient(clientID string, add bool) error {
+	b.dirMu.Lock()
+	defer b.dirMu.Unlock()
+	if add {
+		b.clients[clientID] = struct{}{}
+	} else {
+		delete(b.clients, clientID)
+	}
+	if !b.listed {
+		return nil
+	}
+
+	key := b.clientsKey(b.instanceID)
+	var err error
+	if add {
+		err = b.client.SAdd(b.ctx, key, clientID).Err()
+	} else {
+		err = b.client.SRem(b.ctx, key, clientID).Err()
+	}
+	if err != nil {
+		b.signalLost()
+		return err
+	}
+	return nil
+}
+
+// AddMember records a local presence member, so that a survivor can
+// remove it if this instance dies. It implements hub.PresenceBridge.
+func (b *RedisBridge) AddMember(channel string, m types.Member) error {
+	data, err := json.Marshal(listedMember{Channel: channel, Member: m})
+	if err != nil {
+		return err
+	}
+	b.dirMu.Lock()
+	defer b.dirMu.Unlock()
+	k := memberKey{channel, m.ClientID}
+	old, had := b.members[k]
+	b.members[k] = string(data)
+	if !b.listed {
+		return nil
+	}
+
+	key := b.membersKey(b.instanceID)
+	if had {
+		err = b.client.SRem(b.ctx, key, old).Err()
+	}
+	if err == nil {
+		err = b.client.SAdd(b.ctx, key, string(data)).Err()
+	}
+	if err != nil {
+		b.signalLost()
+		return err
+	}
+	return nil
+}
+
+// RemoveMember forgets a local presence member. It implements
+// hub.PresenceBridge.
+func (b *RedisBridge) RemoveMember(channel, clientID string) error {
+	b.dirMu.Lock()
+	defer b.dirMu.Unlock()
+	k := memberKey{channel, clientID}
+	data, ok := b.members[k]
+	if !ok {
+		return nil
+	}
+	delete(b.members, k)
+	if !b.listed {
+		return nil
+	}
+
+	if err := b.client.SRem(b.ctx, b.membersKey(b.instanceID), data).Err(); err != nil {
+		b.signalLost()
+		return err
+	}
+	return nil
+}
+
+// syncDirectory rewrites this instance's directory and members sets from
+// the local clients and presence members.
+func (b *RedisBridge) syncDirectory() {
+	b.dirMu.Lock()
+	defer b.dirMu.Unlock()
+
+	key := b.clientsKey(b.instanceID)
+	ids := make([]any, 0, len(b.clients))
+	for id := range b.clients {
+		ids = append(ids, id)
+	}
+	mkey := b.membersKey(b.instanceID)
+	members := make([]any, 0, len(b.members))
+	for _, data := range b.members {
+		members = append(members, data)
+	}
+	_, err := b.client.Pipelined(b.ctx, func(p redis.Pipeliner) error {
+		p.Del(b.ctx, key)
+		if len(ids) > 0 {
+			p.SAdd(b.ctx, key, ids...)
+		}
+		p.Del(b.ctx, mkey)
+		if len(members) > 0 {
+			p.SAdd(b.ctx, mkey, members...)
+		}
+		return nil
+	})
+	if err != nil {
+		b.logger.Warn().Err(err).Msg("failed to write client directory")
+		b.signalLost()
+		return
+	}
+	b.listed = true
+}
+
+// directoryLost reports whether this instance's directory set vanished
+// while it holds clients, as when a survivor reaped it during a stall.
+func (b *RedisBridge) directoryLost() bool {
+	b.dirMu.Lock()
+	empty := len(b.clients) == 0
+	b.dirMu.Unlock()
+	if empty {
+		return false
+	}
+	n, err := b.client.Exists(b.ctx, b.clientsKey(b.instanceID)).Result()
+	return err == nil && n == 0
+}
+
+// Locate returns the ID of the live instance whose directory lists
+// clientID. Instances that stopped heartbeating are not consulted.
+func (b *RedisBridge) Locate(clientID string) (string, bool, error) {
+	if b.State() != StateConnected {
+		return "", false, ErrUnavailable
+	}
+	b.dirMu.Lock()
+	_, local := b.clients[clientID]
+	b.dirMu.Unlock()
+	if local {
+		return b.instanceID, true, nil
+	}
+
+	keys, err := b.scan(b.nodeKey("*"))
+	if err != nil {
+		return "", false, err
+	}
+	var ids []string
+	for _, key := range keys {
+		if id := strings.TrimPrefix(key, b.nodeKey("")); id != b.instanceID {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return "", false, nil
+	}
+
+	cmds := make([]*redis.BoolCmd, len(ids))
+	_, err = b.client.Pipelined(b.ctx, func(p redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = p.SIsMember(b.ctx, b.clientsKey(id), clientID)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", false, err
+	}
+	for i, cmd := range cmds {
+		if cmd.Val() {
+			return ids[i], true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// Kick asks the instance holding clientID to disconnect it. It reports
+// false when no instance is subscribed for that client.
+func (b *RedisBridge) Kick(clientID string) (bool, error) {
+	return b.sendDirect(envelope{
+		InstanceID: b.instanceID,
+		Target:     clientID,
+		Op:         opKick,
+	})
+}
+
+// OnNodeDown registers a callback invoked when this instance reaps a dead
+// one, with the clients its directory listed and its presence members.
+// Each dead instance is reported by a single survivor. Callbacks run on
+// the stats goroutine and must not block.
+func (b *RedisBridge) OnNodeDown(cb func(types.NodeDown)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onNodeDown = append(b.onNodeDown, cb)
+}
+
+// reapNodes removes the directory and members sets of instances whose
+// registry entry expired, so their clients stop resolving. A short-lived
+// claim key lets only one survivor reap each instance.
+func (b *RedisBridge) reapNodes() {
+	keys, err := b.scan(b.clientsKey("*"))
+	if err != nil {
+		b.logger.Warn().Err(err).Msg("failed to scan client directory")
+		return
+	}
+	for _, key := range keys {
+		id := strings.TrimPrefix(key, b.clientsKey(""))
+		if id == b.instanceID {
+			continue
+		}
+		if n, err := b.client.Exists(b.ctx, b.nodeKey(id)).Result(); err != nil || n > 0 {
+			continue
+		}
+		claimed, err := b.client.SetNX(b.ctx, b.reapKey(id), b.instanceID, 3*b.statsInterval).Result()
+		if err != nil || !claimed {
+			continue
+		}
+		clients, err := b.client.SMembers(b.ctx, key).Result()
+		if err != nil {
+			continue
+		}
+		listed, err := b.client.SMembers(b.ctx, b.membersKey(id)).Result()
+		if err != nil {
+			continue
+		}
+		// Separate DELs: the keys may live in different cluster slots.
+		if err := b.client.Del(b.ctx, key).Err(); err != nil {
+			continue
+		}
+		if err := b.client.Del(b.ctx, b.membersKey(id)).Err(); err != nil {
+			continue
+		}
+
+		down := types.NodeDown{NodeID: id, Clients: clients, Members: b.decodeMembers(listed)}
+		b.logger.Warn().
+			Str("node", id).
+			Int("clients", len(clients)).
+			Int("members", len(listed)).
+			Msg("node stopped heartbeating, removed from directory")
+		b.mu.RLock()
+		cbs := append([]func(types.NodeDown){}, b.onNodeDown...)
+		b.mu.RUnlock()
+		for _, cb := range cbs {
+			cb(down)
+		}
+	}
+}
+
+// decodeMembers groups the entries of a members set by channel, skipping
+// any that do not decode.
+func (b *RedisBridge) decodeMembers(listed []string) map[string][]types.Member {
+	if len(listed) == 0 {
+		return nil
+	}
+	members := make(map[string][]types.Member)
+	for _, raw := range listed {
+		var lm listedMember
+		if err := json.Unmarshal([]byte(raw), &lm); err != nil {
+			b.logger.Warn().Err(err).Msg("failed to decode presence member")
+			continue
+		}
+		members[lm.Channel] = append(members[lm.Channel], lm.Member)
+	}
+	return members
+}
@@ -0,0 +1,210 @@
+package bridge
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// kickTarget records kicked clients and reports fixed counts.
+type kickTarget struct {
+	clusterTarget
+	kicked []string
+}
+
+func (k *kickTarget) KickLocal(clientID string) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.kicked = append(k.kicked, clientID)
+	return true
+}
+
+func (k *kickTarget) kicks() []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return append([]string(nil), k.kicked...)
+}
+
+// startRegistryBridge starts a bridge with a fast heartbeat against addr.
+func startRegistryBridge(t *testing.T, addr string, target BroadcastTarget) *RedisBridge {
+	t.Helper()
+	rb, _ := testBridgeFor(t, addr, 0, target, func(cfg *RedisConfig) {
+		cfg.NodeAddress = "10.0.0.1:8080"
+		cfg.StatsInterval = 20 * time.Millisecond
+	})
+	waitForState(t, rb, StateConnected)
+	return rb
+}
+
+func TestRedisRegistryEntry(t *testing.T) {
+	srv := newFakeRedis(t)
+	a := startRegistryBridge(t, srv.addr, &kickTarget{})
+	b := startRegistryBridge(t, srv.addr, &kickTarget{})
+
+	nodes, err := a.NodeStats()
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+	for _, n := range nodes {
+		assert.Equal(t, "10.0.0.1:8080", n.Address)
+		assert.False(t, n.StartedAt.IsZero())
+	}
+
+	require.NoError(t, b.Attach("c1"))
+	require.NoError(t, b.Attach("c2"))
+	require.NoError(t, b.Detach("c2"))
+	assert.Equal(t, []string{"c1"}, srv.members(b.clientsKey(b.instanceID)))
+
+	require.NoError(t, b.Stop())
+	assert.Empty(t, srv.members(b.clientsKey(b.instanceID)), "a clean stop removes the directory set")
+	nodes, err = a.NodeStats()
+	require.NoError(t, err)
+	assert.Len(t, nodes, 1)
+}
+
+func TestRedisRegistersBeforeConnected(t *testing.T) {
+	srv := newFakeRedis(t)
+	cfg := DefaultRedisConfig()
+	cfg.Addr = srv.addr
+	cfg.StatsInterval = time.Hour
+	rb := NewRedisBridge(cfg, &kickTarget{}, testLogger())
+	require.NoError(t, rb.Attach("c1"))
+
+	var mu sync.Mutex
+	var registered, listed bool
+	rb.OnStateChange(func(s State) {
+		if s != StateConnected {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, registered = srv.value(rb.nodeKey(rb.instanceID))
+		listed = len(srv.members(rb.clientsKey(rb.instanceID))) == 1
+	})
+	require.NoError(t, rb.Start())
+	t.Cleanup(func() { _ = rb.Stop() })
+	waitForState(t, rb, StateConnected)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.True(t, registered, "the registry entry should exist once connected")
+	assert.True(t, listed, "the directory should be written once connected")
+}
+
+func TestRedisLocateAndKick(t *testing.T) {
+	srv := newFakeRedis(t)
+	a := startRegistryBridge(t, srv.addr, &kickTarget{})
+	tb := &kickTarget{}
+	b := startRegistryBridge(t, srv.addr, tb)
+	require.NoError(t, b.Attach("c1"))
+	require.NoError(t, a.Attach("c0"))
+
+	node, ok, err := a.Locate("c1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, b.NodeID(), node)
+
+	node, ok, err = a.Locate("c0")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, a.NodeID(), node, "local clients resolve to this instance")
+
+	_, ok, err = a.Locate("nobody")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	kicked, err := a.Kick("c1")
+	require.NoError(t, err)
+	assert.True(t, kicked)
+	require.Eventually(t, func() bool { return len(tb.kicks()) == 1 },
+		time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"c1"}, tb.kicks())
+	assert.Empty(t, tb.received(), "a kick is not a direct message")
+
+	kicked, err = a.Kick("nobody")
+	require.NoError(t, err)
+	assert.False(t, kicked)
+}
+
+func TestRedisReapsDeadNode(t *testing.T) {
+	srv := newFakeRedis(t)
+	a := startRegistryBridge(t, srv.addr, &kickTarget{})
+	b := startRegistryBridge(t, srv.addr, &kickTarget{})
+
+	var mu sync.Mutex
+	var reports []types.NodeDown
+	for _, rb := range []*RedisBridge{a, b} {
+		rb.OnNodeDown(func(down types.NodeDown) {
+			mu.Lock()
+			defer mu.Unlock()
+			reports = append(reports, down)
+		})
+	}
+
+	// A node that crashed leaves its directory sets but no registry entry.
+	member := types.Member{ClientID: "c9", UserID: "u9"}
+	data, err := json.Marshal(listedMember{Channel: "presence-room", Member: member})
+	require.NoError(t, err)
+	srv.setCommand([]string{"SADD", a.clientsKey("ghost"), "c9"})
+	srv.setCommand([]string{"SADD", a.membersKey("ghost"), string(data)})
+
+	require.Eventually(t, func() bool { return len(srv.members(a.clientsKey("ghost"))) == 0 },
+		time.Second, 5*time.Millisecond, "the dead node's directory should be removed")
+	time.Sleep(60 * time.Millisecond)
+	assert.Empty(t, srv.members(a.membersKey("ghost")), "the dead node's members should be removed")
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, reports, 1, "exactly one survivor reports the dead node")
+	assert.Equal(t, "ghost", reports[0].NodeID)
+	assert.Equal(t, []string{"c9"}, reports[0].Clients)
+	assert.Equal(t, map[string][]types.Member{"presence-room": {member}}, reports[0].Members)
+}
+
+func TestRedisPresenceMembers(t *testing.T) {
+	srv := newFakeRedis(t)
+	a := startRegistryBridge(t, srv.addr, &kickTarget{})
+	key := a.membersKey(a.instanceID)
+
+	require.NoError(t, a.Attach("c1"))
+	require.NoError(t, a.AddMember("presence-room", types.Member{ClientID: "c1"}))
+	require.NoError(t, a.AddMember("presence-room", types.Member{ClientID: "c1", Info: map[string]any{"away": true}}))
+	require.NoError(t, a.AddMember("presence-lobby", types.Member{ClientID: "c1"}))
+	assert.Len(t, srv.members(key), 2, "re-adding a member replaces it")
+
+	require.NoError(t, a.RemoveMember("presence-lobby", "c1"))
+	require.NoError(t, a.RemoveMember("presence-lobby", "c1"))
+	listed := srv.members(key)
+	require.Len(t, listed, 1)
+	assert.Equal(t, map[string][]types.Member{
+		"presence-room": {{ClientID: "c1", Info: map[string]any{"away": true}}},
+	}, a.decodeMembers(listed))
+
+	// A survivor reaped this node during a stall.
+	srv.keyCommand([]string{"DEL", a.clientsKey(a.instanceID), key})
+	require.Eventually(t, func() bool { return len(srv.members(key)) == 1 },
+		time.Second, 5*time.Millisecond, "the next heartbeat should rewrite the members")
+
+	require.NoError(t, a.Stop())
+	assert.Empty(t, srv.members(key), "a clean stop removes the members set")
+}
+
+func TestRedisDirectoryResyncsAfterReap(t *testing.T) {
+	srv := newFakeRedis(t)
+	a := startRegistryBridge(t, srv.addr, &kickTarget{})
+	require.NoError(t, a.Attach("c1"))
+
+	// A survivor reaped this node during a stall.
+	srv.keyCommand([]string{"DEL", a.clientsKey(a.instanceID)})
+
+	require.Eventually(t, func() bool { return len(srv.members(a.clientsKey(a.instanceID))) == 1 },
+		time.Second, 5*time.Millisecond, "the next heartbeat should rewrite the directory")
+}
+
+func TestRedisNodeAddressFromEnv(t *testing.T) {
+	t.Setenv("REDIS_NODE_ADDRESS", "ws-1.internal:8080")
+	assert.Equal(t, "ws-1.internal:8080", RedisConfigFromEnv().NodeAddress)
+}
@@ -0,0 +1,201 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/redis/go-redis/v9"
+)
+
+// nodeKey is the Redis key holding an instance's latest NodeStats.
+func (b *RedisBridge) nodeKey(instanceID string) string {
+	return b.prefix + "node:" + instanceID
+}
+
+// localStats snapshots the hub's counts, if the hub reports them.
+func (b *RedisBridge) localStats() (types.NodeStats, bool) {
+	src, ok := b.hub.(StatsSource)
+	if !ok {
+		return types.NodeStats{}, false
+	}
+	return types.NodeStats{
+		InstanceID: b.instanceID,
+		Address:    b.address,
+		StartedAt:  b.startedAt,
+		Clients:    src.ClientCount(),
+		Channels:   src.Channels(),
+		UpdatedAt:  time.Now(),
+	}, true
+}
+
+// reportStats refreshes this instance's registry entry every
+// statsInterval and reaps instances that stopped doing the same. The key
+// expires after three missed heartbeats, dropping a dead instance from
+// the cluster view.
+func (b *RedisBridge) reportStats() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.statsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+			if b.State() == StateConnected {
+				b.heartbeat(false)
+				b.reapNodes()
+			}
+		}
+	}
+}
+
+// heartbeat publishes the local registry entry with a TTL. The directory
+// set is rewritten when resync is set, or when the entry had expired or
+// the set is gone, since another instance may have reaped it meanwhile.
+func (b *RedisBridge) heartbeat(resync bool) {
+	stats, ok := b.localStats()
+	if !ok {
+		return
+	}
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return
+	}
+	err = b.client.SetArgs(b.ctx, b.nodeKey(b.instanceID), data, redis.SetArgs{
+		TTL: 3 * b.statsInterval,
+		Get: true,
+	}).Err()
+	if errors.Is(err, redis.Nil) {
+		resync, err = true, nil
+	}
+	if err != nil {
+		b.logger.Warn().Err(err).Msg("failed to publish node stats")
+		b.signalLost()
+		return
+	}
+	if resync || b.directoryLost() {
+		b.syncDirectory()
+	}
+}
+
+// deregister deletes this instance's directory sets and registry entry on
+// a clean shutdown so it leaves the cluster view immediately instead of
+// after its TTL, without being reaped as dead.
+func (b *RedisBridge) deregister() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	// Separate DELs: the keys may live in different cluster slots.
+	for _, key := range []string{b.clientsKey(b.instanceID), b.membersKey(b.instanceID), b.nodeKey(b.instanceID)} {
+		if err := b.client.Del(ctx, key).Err(); err != nil {
+			b.logger.Warn().Err(err).Msg("failed to remove node from registry")
+			return
+		}
+	}
+}
+
+// NodeStats returns the latest counts of every live instance. This
+// instance's entry is taken fresh from the hub rather than from Redis.
+func (b *RedisBridge) NodeStats() ([]types.NodeStats, error) {
+	if b.State() != StateConnected {
+		return nil, ErrUnavailable
+	}
+
+	keys, err := b.scan(b.nodeKey("*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []types.NodeStats
+	self, hasSelf := b.localStats()
+	if hasSelf {
+		nodes = append(nodes, self)
+	}
+	if len(keys) == 0 {
+		return nodes, nil
+	}
+
+	values, err := b.loadNodeValues(keys)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		raw, ok := v.(string)
+		if !ok {
+			continue // expired between SCAN and MGET
+		}
+		var n types.NodeStats
+		if err := json.Unmarshal([]byte(raw), &n); err != nil {
+			b.logger.Warn().Err(err).Msg("failed to decode node stats")
+			continue
+		}
+		if hasSelf && n.InstanceID == b.instanceID {
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+// scan lists the keys matching pattern. A cluster spreads them over its
+// masters, so each one is scanned.
+func (b *RedisBridge) scan(pattern string) ([]string, error) {
+	cc, ok := b.client.(*redis.ClusterClient)
+	if !ok {
+		return scanKeys(b.ctx, b.client, pattern)
+	}
+	var mu sync.Mutex
+	var keys []string
+	err := cc.ForEachMaster(b.ctx, func(ctx context.Context, node *redis.Client) error {
+		batch, err := scanKeys(ctx, node, pattern)
+		mu.Lock()
+		keys = append(keys, batch...)
+		mu.Unlock()
+		return err
+	})
+	return keys, err
+}
+
+func scanKeys(ctx context.Context, c redis.Cmdable, pattern string) ([]string, error) {
+	var keys []string
+	var cursor uint64
+	for {
+		batch, next, err := c.Scan(ctx, cursor, pattern, 100).Result()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, batch...)
+		if cursor = next; cursor == 0 {
+			return keys, nil
+		}
+	}
+}
+
+// loadNodeValues reads the stats keys, nil for any that expired. MGET
+// cannot span cluster slots, so a cluster reads them with pipelined GETs.
+func (b *RedisBridge) loadNodeValues(keys []string) ([]any, error) {
+	if !b.cluster {
+		return b.client.MGet(b.ctx, keys...).Result()
+	}
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err := b.client.Pipelined(b.ctx, func(p redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = p.Get(b.ctx, key)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	values := make([]any, len(keys))
+	for i, cmd := range cmds {
+		if v, err := cmd.Result(); err == nil {
+			values[i] = v
+		}
+	}
+	return values, nil
+}
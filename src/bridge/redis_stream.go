@@ -0,0 +1,146 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// streamField is the stream entry field holding the envelope.
+const streamField = "m"
+
+// appendStream adds an envelope to the stream, trimming it to about
+// streamMaxLen entries.
+func (b *RedisBridge) appendStream(ctx context.Context, data []byte) error {
+	return b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: b.streamKey,
+		MaxLen: b.streamMaxLen,
+		Approx: true,
+		Values: []any{streamField, data},
+	}).Err()
+}
+
+// positionTTL is how long a saved stream position outlives its last
+// update when entries are not trimmed by age, so that positions of
+// instances that never return do not accumulate.
+const positionTTL = 24 * time.Hour
+
+// resumeStream picks the position the next reader starts from: the last
+// entry relayed by this process, else the position saved by a previous
+// run under the same consumer name, else the current end of the stream.
+func (b *RedisBridge) resumeStream() error {
+	if b.streamPosition() != "" {
+		return nil
+	}
+	pos, err := b.client.Get(b.ctx, b.posKey).Result()
+	if errors.Is(err, redis.Nil) {
+		var last []redis.XMessage
+		last, err = b.client.XRevRangeN(b.ctx, b.streamKey, "+", "-", 1).Result()
+		pos = "0-0"
+		if len(last) > 0 {
+			pos = last[0].ID
+		}
+	}
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.lastID = pos
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *RedisBridge) streamPosition() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.lastID
+}
+
+// readStream relays stream entries after the current position until ctx
+// ends, saving the position after every batch so a restarted instance
+// with the same consumer name catches up from there.
+func (b *RedisBridge) readStream(ctx context.Context) {
+	defer b.wg.Done()
+
+	for ctx.Err() == nil {
+		streams, err := b.client.XRead(ctx, &redis.XReadArgs{
+			Streams: []string{b.streamKey, b.streamPosition()},
+			Count:   100,
+			Block:   b.healthInterval,
+		}).Result()
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, redis.Nil) {
+			continue // no new entries within the block time
+		}
+		if err != nil {
+			b.logger.Warn().Err(err).Msg("failed to read redis stream")
+			b.signalLost()
+			select {
+			case <-time.After(b.healthInterval):
+			case <-ctx.Done():
+			}
+			continue
+		}
+
+		var last string
+		for _, s := range streams {
+			for _, entry := range s.Messages {
+				b.handleStreamEntry(entry)
+				last = entry.ID
+			}
+		}
+		if last == "" {
+			continue
+		}
+		b.mu.Lock()
+		b.lastID = last
+		b.mu.Unlock()
+		ttl := b.streamMaxAge
+		if ttl <= 0 {
+			ttl = positionTTL
+		}
+		if err := b.client.Set(ctx, b.posKey, last, ttl).Err(); err != nil && ctx.Err() == nil {
+			b.logger.Warn().Err(err).Msg("failed to save stream position")
+		}
+	}
+}
+
+// handleStreamEntry decodes a stream entry and relays it to the hub.
+func (b *RedisBridge) handleStreamEntry(entry redis.XMessage) {
+	payload, _ := entry.Values[streamField].(string)
+	var env envelope
+	if err := json.Unmarshal([]byte(payload), &env); err != nil {
+		b.logger.Error().Err(err).Str("id", entry.ID).Msg("failed to decode stream entry")
+		return
+	}
+	b.relay(env)
+}
+
+// trimStream drops entries older than streamMaxAge. Every instance trims,
+// since no single reader knows when all others have caught up.
+func (b *RedisBridge) trimStream() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(max(b.streamMaxAge/10, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if b.State() != StateConnected {
+			continue
+		}
+		minID := strconv.FormatInt(time.Now().Add(-b.streamMaxAge).UnixMilli(), 10)
+		if err := b.client.XTrimMinIDApprox(b.ctx, b.streamKey, minID, 0).Err(); err != nil {
+			b.logger.Warn().Err(err).Msg("failed to trim redis stream")
+		}
+	}
+}
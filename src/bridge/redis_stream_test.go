@@ -0,0 +1,143 @@
+package bridge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startStreamBridge starts a streams-mode bridge named consumer against addr.
+func startStreamBridge(t *testing.T, addr, consumer string, target BroadcastTarget) *RedisBridge {
+	t.Helper()
+	rb, _ := testBridgeFor(t, addr, 10, target, func(cfg *RedisConfig) {
+		cfg.Streams = true
+		cfg.StreamConsumer = consumer
+	})
+	waitForState(t, rb, StateConnected)
+	return rb
+}
+
+func events(msgs []types.Message) []string {
+	var out []string
+	for _, m := range msgs {
+		out = append(out, m.Event)
+	}
+	return out
+}
+
+func TestRedisStreamsRelay(t *testing.T) {
+	srv := newFakeRedis(t)
+	ta, tb := &recordingTarget{}, &recordingTarget{}
+	a := startStreamBridge(t, srv.addr, "node-a", ta)
+	startStreamBridge(t, srv.addr, "node-b", tb)
+
+	require.NoError(t, a.Watch("news"))
+	assert.False(t, srv.subscribed(a.channelKey("news")), "streams mode should not subscribe per channel")
+
+	require.NoError(t, a.Publish(types.Message{Channel: "news", Event: "one"}))
+	require.Eventually(t, func() bool { return len(tb.received()) == 1 },
+		time.Second, 5*time.Millisecond)
+	assert.Empty(t, ta.received(), "a bridge should skip its own entries")
+	assert.Equal(t, 1, srv.streamLen(a.streamKey))
+}
+
+func TestRedisStreamsCatchUpAfterRestart(t *testing.T) {
+	srv := newFakeRedis(t)
+	a := startStreamBridge(t, srv.addr, "node-a", &recordingTarget{})
+	first := &recordingTarget{}
+	b := startStreamBridge(t, srv.addr, "node-b", first)
+
+	require.NoError(t, a.Publish(types.Message{Channel: "news", Event: "before"}))
+	require.Eventually(t, func() bool { return len(first.received()) == 1 },
+		time.Second, 5*time.Millisecond)
+	require.NoError(t, b.Stop())
+
+	require.NoError(t, a.Publish(types.Message{Channel: "news", Event: "missed-1"}))
+	require.NoError(t, a.Publish(types.Message{Channel: "news", Event: "missed-2"}))
+
+	second := &recordingTarget{}
+	startStreamBridge(t, srv.addr, "node-b", second)
+	require.Eventually(t, func() bool { return len(second.received()) == 2 },
+		time.Second, 5*time.Millisecond, "restarted node should catch up from its saved position")
+	assert.Equal(t, []string{"missed-1", "missed-2"}, events(second.received()))
+
+	fresh := &recordingTarget{}
+	startStreamBridge(t, srv.addr, "node-c", fresh)
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, fresh.received(), "a new consumer should start at the end of the stream")
+}
+
+func TestRedisStreamsCatchUpAfterOutage(t *testing.T) {
+	srv := newFakeRedis(t)
+	a := startStreamBridge(t, srv.addr, "node-a", &recordingTarget{})
+	tb := &recordingTarget{}
+	b := startStreamBridge(t, srv.addr, "node-b", tb)
+
+	srv.stop()
+	waitForState(t, a, StateDegraded)
+	waitForState(t, b, StateDegraded)
+	require.NoError(t, a.Publish(types.Message{Channel: "news", Event: "during"}))
+
+	srv.start()
+	require.Eventually(t, func() bool { return len(tb.received()) == 1 },
+		2*time.Second, 5*time.Millisecond, "buffered entry should reach the other node after reconnect")
+	assert.Equal(t, []string{"during"}, events(tb.received()))
+}
+
+func TestRedisStreamsTrimByLength(t *testing.T) {
+	srv := newFakeRedis(t)
+	cfg := DefaultRedisConfig()
+	cfg.Addr = srv.addr
+	cfg.Streams = true
+	cfg.StreamMaxLen = 3
+	cfg.HealthInterval = 20 * time.Millisecond
+	rb := NewRedisBridge(cfg, &recordingTarget{}, testLogger())
+	require.NoError(t, rb.Start())
+	t.Cleanup(func() { _ = rb.Stop() })
+	waitForState(t, rb, StateConnected)
+
+	for range 5 {
+		require.NoError(t, rb.Publish(types.Message{Channel: "news"}))
+	}
+	assert.Equal(t, 3, srv.streamLen(rb.streamKey))
+}
+
+func TestRedisStreamsPositionAlwaysExpires(t *testing.T) {
+	srv := newFakeRedis(t)
+	a := startStreamBridge(t, srv.addr, "node-a", &recordingTarget{})
+	tb := &recordingTarget{}
+	b, _ := testBridgeFor(t, srv.addr, 0, tb, func(cfg *RedisConfig) {
+		cfg.Streams = true
+		cfg.StreamMaxAge = 0
+	})
+	waitForState(t, b, StateConnected)
+
+	require.NoError(t, a.Publish(types.Message{Channel: "news"}))
+	require.Eventually(t, func() bool { return len(tb.received()) == 1 },
+		time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool { return !srv.expiry(b.posKey).IsZero() },
+		time.Second, 5*time.Millisecond, "the position should be saved with a TTL")
+	assert.WithinDuration(t, time.Now().Add(positionTTL), srv.expiry(b.posKey), time.Minute)
+}
+
+func TestRedisStreamsConsumerDefaultsToInstance(t *testing.T) {
+	cfg := DefaultRedisConfig()
+	cfg.Streams = true
+	a := NewRedisBridge(cfg, &recordingTarget{}, testLogger())
+	b := NewRedisBridge(cfg, &recordingTarget{}, testLogger())
+	assert.Equal(t, cfg.Prefix+"stream:pos:"+a.NodeID(), a.posKey)
+	assert.NotEqual(t, a.posKey, b.posKey, "instances on one host must not share a position")
+}
+
+func TestRedisStreamsConfigFromEnv(t *testing.T) {
+	t.Setenv("REDIS_STREAMS", "true")
+	t.Setenv("REDIS_STREAM_CONSUMER", "web-1")
+
+	cfg := RedisConfigFromEnv()
+	assert.True(t, cfg.Streams)
+	assert.Equal(t, "web-1", cfg.StreamConsumer)
+	assert.Equal(t, int64(10000), cfg.StreamMaxLen)
+}
@@ -2,6 +2,7 @@ package bridge
 
 import (
 	"encoding/json"
+	"sync"
 	"testing"
 	"time"
 
@@ -29,7 +30,7 @@ func TestRedisEnvelopeSerialization(t *testing.T) {
 		Timestamp: time.Now().Truncate(time.Second),
 	}
 
-	env := redisEnvelope{
+	env := envelope{
 		InstanceID: "instance-abc",
 		Message:    msg,
 	}
@@ -37,7 +38,7 @@ func TestRedisEnvelopeSerialization(t *testing.T) {
 	data, err := json.Marshal(env)
 	require.NoError(t, err)
 
-	var decoded redisEnvelope
+	var decoded envelope
 	err = json.Unmarshal(data, &decoded)
 	require.NoError(t, err)
 
@@ -56,7 +57,7 @@ func TestRedisEnvelopeRoundTrip(t *testing.T) {
 		Timestamp: time.Now().Truncate(time.Millisecond),
 	}
 
-	env := redisEnvelope{
+	env := envelope{
 		InstanceID: "node-1",
 		Message:    msg,
 	}
@@ -64,7 +65,7 @@ func TestRedisEnvelopeRoundTrip(t *testing.T) {
 	data, err := json.Marshal(env)
 	require.NoError(t, err)
 
-	var out redisEnvelope
+	var out envelope
 	require.NoError(t, json.Unmarshal(data, &out))
 
 	assert.Equal(t, "node-1", out.InstanceID)
@@ -74,6 +75,29 @@ func TestRedisEnvelopeRoundTrip(t *testing.T) {
 	assert.Equal(t, float64(5), out.Message.Data["count"])
 }
 
+func TestRedisEnvelopeDirectTarget(t *testing.T) {
+	env := envelope{
+		InstanceID: "node-1",
+		Target:     "client-9",
+		Message:    types.Message{Channel: "dm", Event: "ping"},
+	}
+
+	data, err := json.Marshal(env)
+	require.NoError(t, err)
+
+	var out envelope
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, "client-9", out.Target)
+	assert.Equal(t, "dm", out.Message.Channel)
+}
+
+func TestRedisBridgeChannelKeys(t *testing.T) {
+	cfg := DefaultRedisConfig()
+	rb := NewRedisBridge(cfg, &mockBroadcastTarget{}, testLogger())
+	assert.Equal(t, "orchestra:ws:client:abc", rb.clientChannel("abc"))
+	assert.Equal(t, "orchestra:ws:channel:news", rb.channelKey("news"))
+}
+
 func TestDefaultRedisConfig(t *testing.T) {
 	cfg := DefaultRedisConfig()
 	assert.Equal(t, "localhost:6379", cfg.Addr)
@@ -129,3 +153,149 @@ func TestRedisBridgeInstanceIDUnique(t *testing.T) {
 func testLogger() zerolog.Logger {
 	return zerolog.Nop()
 }
+
+// testBridge returns a started bridge against addr with fast reconnects.
+func testBridge(t *testing.T, addr string, bufferSize int) (*RedisBridge, *stateLog) {
+	return testBridgeFor(t, addr, bufferSize, &mockBroadcastTarget{}, nil)
+}
+
+// testBridgeFor returns a started bridge against addr with fast
+// reconnects, relaying to target. A non-nil configure adjusts the config
+// before the bridge is created.
+func testBridgeFor(t *testing.T, addr string, bufferSize int, target BroadcastTarget, configure func(*RedisConfig)) (*RedisBridge, *stateLog) {
+	t.Helper()
+	cfg := DefaultRedisConfig()
+	cfg.Addr = addr
+	cfg.ReconnectMin = 10 * time.Millisecond
+	cfg.ReconnectMax = 40 * time.Millisecond
+	cfg.HealthInterval = 20 * time.Millisecond
+	cfg.BufferSize = bufferSize
+	if configure != nil {
+		configure(cfg)
+	}
+
+	rb := NewRedisBridge(cfg, target, testLogger())
+	log := &stateLog{}
+	rb.OnStateChange(log.record)
+	require.NoError(t, rb.Start())
+	t.Cleanup(func() { _ = rb.Stop() })
+	return rb, log
+}
+
+// stateLog records bridge state transitions.
+type stateLog struct {
+	mu     sync.Mutex
+	states []State
+}
+
+func (l *stateLog) record(s State) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.states = append(l.states, s)
+}
+
+func (l *stateLog) all() []State {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]State(nil), l.states...)
+}
+
+func waitForState(t *testing.T, rb *RedisBridge, want State) {
+	t.Helper()
+	require.Eventually(t, func() bool { return rb.State() == want },
+		2*time.Second, 5*time.Millisecond, "bridge never reached %s", want)
+}
+
+func TestRedisBridgeStartsWhileRedisDown(t *testing.T) {
+	rb, log := testBridge(t, "127.0.0.1:1", 0)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, StateConnecting, rb.State())
+	assert.False(t, rb.Available())
+	assert.ErrorIs(t, rb.Publish(types.Message{Channel: "news"}), ErrUnavailable)
+	_, err := rb.SendDirect("c1", types.Message{})
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	require.NoError(t, rb.Stop())
+	assert.Equal(t, StateStopped, rb.State())
+	assert.Equal(t, []State{StateStopped}, log.all())
+}
+
+func TestRedisBridgeBuffersDuringOutage(t *testing.T) {
+	rb, _ := testBridge(t, "127.0.0.1:1", 2)
+
+	assert.True(t, rb.Available(), "buffering bridge should accept publishes while reconnecting")
+	for _, ch := range []string{"a", "b", "c"} {
+		require.NoError(t, rb.Publish(types.Message{Channel: ch}))
+	}
+
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	require.Len(t, rb.outbox, 2)
+	assert.Equal(t, rb.channelKey("b"), rb.outbox[0].channel, "oldest message should be dropped")
+	assert.Equal(t, 1, rb.dropped)
+}
+
+func TestRedisBridgeReconnectsAndResubscribes(t *testing.T) {
+	srv := newFakeRedis(t)
+	rb, log := testBridge(t, srv.addr, 10)
+
+	require.NoError(t, rb.Watch("news"))
+	require.NoError(t, rb.Attach("client-1"))
+	waitForState(t, rb, StateConnected)
+	require.Eventually(t, func() bool { return srv.subscribed(rb.channelKey("news")) },
+		time.Second, 5*time.Millisecond)
+
+	srv.stop()
+	waitForState(t, rb, StateDegraded)
+	require.NoError(t, rb.Publish(types.Message{Channel: "news", Event: "queued"}))
+
+	srv.start()
+	waitForState(t, rb, StateConnected)
+	assert.True(t, srv.subscribed(rb.channelKey("news")), "channel interest should be restored")
+	assert.True(t, srv.subscribed(rb.clientChannel("client-1")), "client interest should be restored")
+	assert.Contains(t, srv.publishedTo(), rb.channelKey("news"), "buffered publish should be flushed")
+
+	assert.Equal(t, []State{StateConnected, StateDegraded, StateConnected}, log.all())
+}
+
+// statsTarget is a hub stand-in that reports fixed counts.
+type statsTarget struct {
+	mockBroadcastTarget
+	clients  int
+	channels map[string]int
+}
+
+func (s *statsTarget) ClientCount() int         { return s.clients }
+func (s *statsTarget) Channels() map[string]int { return s.channels }
+
+func TestRedisBridgeNodeStats(t *testing.T) {
+	srv := newFakeRedis(t)
+	a, _ := testBridgeFor(t, srv.addr, 0, &statsTarget{clients: 2, channels: map[string]int{"news": 2}}, nil)
+	b, _ := testBridgeFor(t, srv.addr, 0, &statsTarget{clients: 1, channels: map[string]int{"news": 1, "chat": 1}}, nil)
+	waitForState(t, a, StateConnected)
+	waitForState(t, b, StateConnected)
+
+	nodes, err := a.NodeStats()
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+	byID := map[string]types.NodeStats{}
+	for _, n := range nodes {
+		byID[n.InstanceID] = n
+	}
+	assert.Equal(t, 2, byID[a.instanceID].Clients)
+	assert.Equal(t, 1, byID[b.instanceID].Channels["chat"])
+
+	require.NoError(t, b.Stop())
+	_, ok := srv.value(b.nodeKey(b.instanceID))
+	assert.False(t, ok, "a stopped node should remove its stats key")
+	nodes, err = a.NodeStats()
+	require.NoError(t, err)
+	assert.Len(t, nodes, 1)
+}
+
+func TestRedisBridgeNodeStatsUnavailable(t *testing.T) {
+	rb, _ := testBridge(t, "127.0.0.1:1", 0)
+	_, err := rb.NodeStats()
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
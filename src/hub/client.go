@@ -1,7 +1,11 @@
 package hub
 
 import (
+	"context"
+	"errors"
+	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/orchestra-mcp/socket/src/types"
@@ -12,24 +16,39 @@ type Client struct {
 	ID          string
 	conn        types.Conn
 	hub         *Hub
+	shard       *shard
 	Send        chan types.Message
 	connectedAt time.Time
+	userAgent   string
+	identity    types.Identity
+	token       string
 	channels    map[string]bool
 	mu          sync.RWMutex
 	done        chan struct{}
-	closed      bool
+	ctx         context.Context // cancelled when the client leaves
+	cancel      context.CancelFunc
+	reason      types.DisconnectReason
+	closeCode   int
+	dropped     atomic.Uint64
+
+	sendMu sync.RWMutex // guards closed and closing Send
+	closed bool
 }
 
 // NewClient creates a new WebSocket client wrapper.
 func NewClient(id string, conn types.Conn, h *Hub) *Client {
+	ctx, cancel := context.WithCancel(h.ctx)
 	return &Client{
 		ID:          id,
 		conn:        conn,
 		hub:         h,
-		Send:        make(chan types.Message, 256),
+		shard:       h.clientShard(id),
+		Send:        make(chan types.Message, h.sendBuffer),
 		connectedAt: time.Now(),
 		channels:    make(map[string]bool),
 		done:        make(chan struct{}),
+		ctx:         ctx,
+		cancel:      cancel,
 	}
 }
 
@@ -46,9 +65,47 @@ func (c *Client) Info() types.ClientInfo {
 		ID:          c.ID,
 		ConnectedAt: c.connectedAt,
 		Channels:    channels,
+		UserAgent:   c.userAgent,
+		UserID:      c.identity.UserID,
+		Claims:      c.identity.Claims,
+		Dropped:     c.dropped.Load(),
 	}
 }
 
+// SetIdentity attaches the authenticated identity to this client.
+func (c *Client) SetIdentity(id types.Identity) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.identity = id
+}
+
+// Identity returns the authenticated identity, empty for anonymous clients.
+func (c *Client) Identity() types.Identity {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.identity
+}
+
+func (c *Client) setToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+// sessionToken returns the resume token issued to this client, if any.
+func (c *Client) sessionToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+// SetUserAgent records the User-Agent reported at upgrade time.
+func (c *Client) SetUserAgent(ua string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.userAgent = ua
+}
+
 // AddChannel adds a channel subscription.
 func (c *Client) AddChannel(channel string) {
 	c.mu.Lock()
@@ -64,26 +121,59 @@ func (c *Client) RemoveChannel(channel string) {
 }
 
 // ReadPump reads messages from the WebSocket and routes to the hub.
+// When heartbeats are enabled, the read deadline (two ping intervals) is
+// extended on every pong and frame; a client that misses it is
+// unregistered with DisconnectTimeout.
 func (c *Client) ReadPump() {
 	defer func() {
-		c.hub.unregister <- c
+		c.shard.unregister <- c
 		c.conn.Close()
 	}()
 
+	ping, _ := c.hub.heartbeat()
+	hb, _ := c.conn.(types.HeartbeatConn)
+	pongWait := 2 * ping
+	if hb != nil && ping > 0 {
+		_ = hb.SetReadDeadline(time.Now().Add(pongWait))
+		hb.SetPongHandler(func() error {
+			return hb.SetReadDeadline(time.Now().Add(pongWait))
+		})
+	} else {
+		hb = nil
+	}
+
 	for {
 		var msg types.Message
 		if err := c.conn.ReadJSON(&msg); err != nil {
+			c.setReason(readFailure(err))
 			return
 		}
+		if hb != nil {
+			_ = hb.SetReadDeadline(time.Now().Add(pongWait))
+		}
 		msg.ClientID = c.ID
 		msg.Timestamp = time.Now()
-		c.hub.incoming <- msg
+		c.shard.incoming <- msg
 	}
 }
 
 // WritePump writes messages from the send channel to the WebSocket.
+// Broadcast frames are written pre-encoded when the connection implements
+// types.FrameConn. When heartbeats are enabled it also sends a ping every interval and
+// applies the write timeout to each write.
 func (c *Client) WritePump() {
-	defer c.conn.Close()
+	defer c.closeConn()
+
+	ping, write := c.hub.heartbeat()
+	hb, _ := c.conn.(types.HeartbeatConn)
+	fc, _ := c.conn.(types.FrameConn)
+
+	var tick <-chan time.Time
+	if hb != nil && ping > 0 {
+		ticker := time.NewTicker(ping)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
 
 	for {
 		select {
@@ -91,7 +181,22 @@ func (c *Client) WritePump() {
 			if !ok {
 				return
 			}
-			if err := c.conn.WriteJSON(msg); err != nil {
+			if hb != nil {
+				_ = hb.SetWriteDeadline(deadline(write))
+			}
+			var err error
+			if frame := msg.Frame(); fc != nil && frame != nil {
+				err = fc.WriteFrame(frame)
+			} else {
+				err = c.conn.WriteJSON(msg.WithFrame(nil))
+			}
+			if err != nil {
+				c.setReason(types.DisconnectWriteError)
+				return
+			}
+		case <-tick:
+			if err := hb.WritePing(deadline(write)); err != nil {
+				c.setReason(types.DisconnectWriteError)
 				return
 			}
 		case <-c.done:
@@ -100,12 +205,70 @@ func (c *Client) WritePump() {
 	}
 }
 
-// Close signals the client to stop its pumps.
-func (c *Client) Close() {
+// deadline returns now+d, or the zero time (no deadline) when d is zero.
+func deadline(d time.Duration) time.Time {
+	if d <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(d)
+}
+
+// readFailure classifies a read error as a timeout or a plain close.
+func readFailure(err error) types.DisconnectReason {
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return types.DisconnectTimeout
+	}
+	return types.DisconnectClosed
+}
+
+// setReason records why the client is leaving. The first reason wins.
+func (c *Client) setReason(r types.DisconnectReason) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if c.reason == "" {
+		c.reason = r
+	}
+}
+
+func (c *Client) disconnectReason() types.DisconnectReason {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.reason == "" {
+		return types.DisconnectClosed
+	}
+	return c.reason
+}
+
+// markEvicted records a slow-consumer eviction and the close code to send.
+func (c *Client) markEvicted(code int) {
+	c.setReason(types.DisconnectSlowConsumer)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeCode = code
+}
+
+// closeConn closes the connection, sending a close frame first when a
+// close code was recorded and the connection supports it.
+func (c *Client) closeConn() {
+	c.mu.RLock()
+	code := c.closeCode
+	c.mu.RUnlock()
+
+	if cc, ok := c.conn.(types.CloseCoder); ok && code != 0 {
+		_ = cc.CloseWithCode(code, string(types.DisconnectSlowConsumer))
+		return
+	}
+	c.conn.Close()
+}
+
+// Close signals the client to stop its pumps.
+func (c *Client) Close() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
 	if !c.closed {
 		c.closed = true
+		c.cancel()
 		close(c.done)
 		close(c.Send)
 	}
@@ -0,0 +1,119 @@
+package hub
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/auth"
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// CodeNotSubscribed is the error code sent when a client unsubscribes
+// from a channel it is not subscribed to.
+const CodeNotSubscribed = "not_subscribed"
+
+// handleControl processes a frame sent on the reserved system channel.
+func (h *Hub) handleControl(msg types.Message) {
+	channel, _ := msg.Data["channel"].(string)
+
+	switch msg.Event {
+	case types.EventSubscribe:
+		if err := h.controlSubscribe(msg.ClientID, channel, subscribeOptions(msg.Data)); err != nil {
+			h.replyError(msg, channel, err)
+			return
+		}
+		h.replyControl(msg.ClientID, types.EventSubscribed, channel)
+	case types.EventUnsubscribe:
+		if err := h.controlUnsubscribe(msg.ClientID, channel); err != nil {
+			h.replyError(msg, channel, err)
+			return
+		}
+		h.replyControl(msg.ClientID, types.EventUnsubscribed, channel)
+	case types.EventRPC:
+		h.handleRPC(msg)
+	case types.EventRPCResult:
+		h.resolveCall(msg)
+	default:
+		h.replyError(msg, channel,
+			fmt.Errorf("unknown control event %q", msg.Event))
+	}
+}
+
+func (h *Hub) controlSubscribe(clientID, channel string, opts SubscribeOptions) error {
+	if err := validateChannel(channel); err != nil {
+		return err
+	}
+
+	if err := h.Authorize(clientID, channel, auth.ActionSubscribe); err != nil {
+		return err
+	}
+
+	h.mu.RLock()
+	authorize := h.authorize
+	h.mu.RUnlock()
+
+	if authorize != nil {
+		if err := authorize(clientID, channel); err != nil {
+			return err
+		}
+	}
+	return h.subscribe(channel, clientID, opts)
+}
+
+// subscribeOptions reads the optional "member" object and "history" count
+// from a subscribe frame.
+func subscribeOptions(data map[string]any) SubscribeOptions {
+	var opts SubscribeOptions
+	opts.Member, _ = data["member"].(map[string]any)
+	if n, ok := data["history"].(float64); ok && n > 0 {
+		opts.History = int(n)
+	}
+	return opts
+}
+
+func (h *Hub) controlUnsubscribe(clientID, channel string) error {
+	if err := validateChannel(channel); err != nil {
+		return err
+	}
+	if !h.Unsubscribe(channel, clientID) {
+		return &HandlerError{Code: CodeNotSubscribed, Message: "not subscribed to " + channel}
+	}
+	return nil
+}
+
+// validateChannel rejects empty and reserved channel names.
+func validateChannel(channel string) error {
+	if channel == "" {
+		return fmt.Errorf("channel is required")
+	}
+	if channel == types.SystemChannel {
+		return fmt.Errorf("channel %s is reserved", channel)
+	}
+	return nil
+}
+
+// replyControl sends a confirmation frame on the system channel.
+func (h *Hub) replyControl(clientID, event, channel string) {
+	h.SendToClient(clientID, types.Message{
+		Channel:   types.SystemChannel,
+		Event:     event,
+		Data:      map[string]any{"channel": channel},
+		Timestamp: time.Now(),
+	})
+}
+
+// replyError sends an error frame on the system channel describing
+// which control request failed and why. A *HandlerError sets the code.
+func (h *Hub) replyError(req types.Message, channel string, err error) {
+	h.logger.Debug().Err(err).
+		Str("client_id", req.ClientID).
+		Str("event", req.Event).
+		Str("channel", channel).
+		Msg("control request rejected")
+
+	code, message := "", err.Error()
+	if he, ok := err.(*HandlerError); ok {
+		code, message = he.Code, he.Message
+	}
+	h.SendToClient(req.ClientID, errorFrame(req, channel, code, message))
+}
@@ -0,0 +1,119 @@
+package hub
+
+import (
+	"fmt"
+
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// directoryBridge returns the bridge as a DirectoryBridge, or nil.
+func (h *Hub) directoryBridge() DirectoryBridge {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	db, _ := h.bridge.(DirectoryBridge)
+	return db
+}
+
+// LocateClient reports which instance holds a connected client. Clients
+// on this instance are answered locally; others are looked up in the
+// bridge's directory. ErrClientNotFound means no live instance lists it.
+func (h *Hub) LocateClient(clientID string) (types.ClientLocation, error) {
+	loc := types.ClientLocation{ClientID: clientID}
+	db := h.directoryBridge()
+
+	h.mu.RLock()
+	_, local := h.clients[clientID]
+	h.mu.RUnlock()
+	if local {
+		loc.InstanceID, loc.Local = "local", true
+		if db != nil {
+			loc.InstanceID = db.NodeID()
+		}
+		return loc, nil
+	}
+
+	if db != nil && h.bridgeAvailable() {
+		id, ok, err := db.Locate(clientID)
+		if err != nil {
+			return loc, fmt.Errorf("locate %s: %w", clientID, err)
+		}
+		if ok {
+			loc.InstanceID = id
+			return loc, nil
+		}
+	}
+	return loc, fmt.Errorf("%w: %s", ErrClientNotFound, clientID)
+}
+
+// Kick disconnects a client wherever it is connected and reports where it
+// was. The client leaves with DisconnectServer, so it cannot resume its
+// session. ErrClientNotFound means no instance held it.
+func (h *Hub) Kick(clientID string) (Delivery, error) {
+	if h.KickLocal(clientID) {
+		return DeliveredLocal, nil
+	}
+	if db := h.directoryBridge(); db != nil && h.bridgeAvailable() {
+		kicked, err := db.Kick(clientID)
+		if err != nil {
+			return 0, fmt.Errorf("kick %s: %w", clientID, err)
+		}
+		if kicked {
+			return DeliveredRemote, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrClientNotFound, clientID)
+}
+
+// KickLocal disconnects a client on this instance, or discards its
+// session if it is inside its resume grace period. It implements
+// bridge.KickTarget.
+func (h *Hub) KickLocal(clientID string) bool {
+	h.mu.Lock()
+	client, ok := h.clients[clientID]
+	s := h.sessions[clientID]
+	if !ok && s != nil {
+		h.dropSession(clientID, s)
+	}
+	h.mu.Unlock()
+
+	switch {
+	case ok:
+		client.setReason(types.DisconnectServer)
+		h.removeClient(client)
+		return true
+	case s != nil:
+		h.detachClient(clientID)
+		h.syncInterest(s.channels...)
+		h.logger.Info().Str("client_id", clientID).Msg("detached session kicked")
+		return true
+	}
+	return false
+}
+
+// OnNodeDown registers a callback for instances the bridge found dead,
+// called with the clients they held. Each dead instance is reported on
+// one surviving instance only.
+func (h *Hub) OnNodeDown(cb func(nodeID string, clients []string)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onNodeDown = append(h.onNodeDown, cb)
+}
+
+// nodeDown handles a dead instance reported by the bridge: its presence
+// members are announced as removed to local subscribers, like any other
+// presence event, then callbacks run.
+func (h *Hub) nodeDown(down types.NodeDown) {
+	h.mu.RLock()
+	cbs := append([]func(string, []string){}, h.onNodeDown...)
+	h.mu.RUnlock()
+
+	h.logger.Warn().Str("node", down.NodeID).Int("clients", len(down.Clients)).Msg("cluster node down")
+	for channel, members := range down.Members {
+		for _, m := range members {
+			h.announceLeave(channel, m)
+		}
+	}
+	for _, cb := range cbs {
+		cb(down.NodeID, down.Clients)
+	}
+}
@@ -0,0 +1,177 @@
+package hub
+
+import (
+	"errors"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// HistoryPolicy bounds how much of a channel's traffic the hub retains.
+// Limit caps the number of messages and MaxAge drops older ones; zero
+// disables that bound, but a policy needs at least one of them. A policy
+// with Limit 1 is a last-value cache.
+type HistoryPolicy struct {
+	Limit  int
+	MaxAge time.Duration
+}
+
+// HistoryOptions filters a History query.
+type HistoryOptions struct {
+	Limit int       // newest N messages; 0 returns all retained
+	Since time.Time // only messages recorded after Since
+}
+
+type historyRule struct {
+	pattern string
+	policy  HistoryPolicy
+}
+
+type historyEntry struct {
+	at  time.Time
+	msg types.Message
+}
+
+// history stores retained messages per channel under its own lock so
+// recording does not contend with subscription changes.
+type history struct {
+	mu       sync.Mutex
+	rules    []historyRule
+	channels map[string][]historyEntry
+}
+
+// historySweepInterval is how often Run drops expired messages from
+// channels that are no longer published to or queried.
+const historySweepInterval = 30 * time.Second
+
+var errUnboundedHistory = errors.New("history policy needs a positive Limit or MaxAge")
+
+func newHistory() history {
+	return history{channels: make(map[string][]historyEntry)}
+}
+
+// SetChannelHistory retains messages published on channels matching
+// pattern (path.Match syntax). The first matching pattern applies;
+// setting an existing pattern again replaces its policy. Like the
+// plugin's history config, a policy must bound retention: one with
+// neither a positive Limit nor MaxAge, or a negative bound, is rejected.
+func (h *Hub) SetChannelHistory(pattern string, p HistoryPolicy) error {
+	if p.Limit < 0 || p.MaxAge < 0 || (p.Limit == 0 && p.MaxAge == 0) {
+		return errUnboundedHistory
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return err
+	}
+	h.history.mu.Lock()
+	defer h.history.mu.Unlock()
+	for i, r := range h.history.rules {
+		if r.pattern == pattern {
+			h.history.rules[i].policy = p
+			return nil
+		}
+	}
+	h.history.rules = append(h.history.rules, historyRule{pattern: pattern, policy: p})
+	return nil
+}
+
+// policyFor returns the history policy for channel. Caller holds history.mu.
+func (hs *history) policyFor(channel string) (HistoryPolicy, bool) {
+	for _, r := range hs.rules {
+		if ok, _ := path.Match(r.pattern, channel); ok {
+			return r.policy, true
+		}
+	}
+	return HistoryPolicy{}, false
+}
+
+// record appends a published message to its channel's history.
+func (h *Hub) record(channel string, msg types.Message) {
+	hs := &h.history
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	p, ok := hs.policyFor(channel)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	entries := append(hs.channels[channel], historyEntry{at: now, msg: msg})
+	hs.channels[channel] = p.trim(entries, now)
+}
+
+// trim applies the policy bounds, keeping the newest entries.
+func (p HistoryPolicy) trim(entries []historyEntry, now time.Time) []historyEntry {
+	start := 0
+	if p.Limit > 0 && len(entries) > p.Limit {
+		start = len(entries) - p.Limit
+	}
+	if p.MaxAge > 0 {
+		cutoff := now.Add(-p.MaxAge)
+		for start < len(entries) && entries[start].at.Before(cutoff) {
+			start++
+		}
+	}
+	if start == 0 {
+		return entries
+	}
+	return append(entries[:0], entries[start:]...)
+}
+
+// runHistorySweep periodically trims every retained channel, so that
+// MaxAge also applies to channels that went idle, and forgets channels
+// left empty. It returns on Stop.
+func (h *Hub) runHistorySweep() {
+	ticker := time.NewTicker(historySweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.done:
+			return
+		case now := <-ticker.C:
+			h.history.sweep(now)
+		}
+	}
+}
+
+// sweep trims all channels against their policies at now.
+func (hs *history) sweep(now time.Time) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	for channel, entries := range hs.channels {
+		if p, ok := hs.policyFor(channel); ok {
+			entries = p.trim(entries, now)
+		}
+		if len(entries) == 0 {
+			delete(hs.channels, channel)
+			continue
+		}
+		hs.channels[channel] = entries
+	}
+}
+
+// History returns retained messages for a channel, oldest first.
+func (h *Hub) History(channel string, opts HistoryOptions) []types.Message {
+	hs := &h.history
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	entries := hs.channels[channel]
+	if p, ok := hs.policyFor(channel); ok {
+		entries = p.trim(entries, time.Now())
+		hs.channels[channel] = entries
+	}
+
+	out := make([]types.Message, 0, len(entries))
+	for _, e := range entries {
+		if !opts.Since.IsZero() && !e.at.After(opts.Since) {
+			continue
+		}
+		out = append(out, e.msg)
+	}
+	if opts.Limit > 0 && len(out) > opts.Limit {
+		out = out[len(out)-opts.Limit:]
+	}
+	return out
+}
@@ -1,8 +1,12 @@
 package hub
 
 import (
+	"context"
+	"runtime"
 	"sync"
+	"time"
 
+	"github.com/orchestra-mcp/socket/src/auth"
 	"github.com/orchestra-mcp/socket/src/types"
 	"github.com/rs/zerolog"
 )
@@ -14,25 +18,92 @@ type MessageBridge interface {
 	Available() bool
 }
 
+// DirectBridge is implemented by bridges that can route a message to a
+// client connected to another instance. Attach and Detach announce local
+// clients; SendDirect reports whether any instance accepted the message.
+type DirectBridge interface {
+	Attach(clientID string) error
+	Detach(clientID string) error
+	SendDirect(clientID string, msg types.Message) (bool, error)
+}
+
+// ChannelBridge is implemented by bridges that subscribe to remote traffic
+// per channel. The hub calls Watch when a channel gains local interest
+// (a live subscriber or a detached session) and Unwatch when it loses it.
+type ChannelBridge interface {
+	Watch(channel string) error
+	Unwatch(channel string) error
+}
+
+// ClusterBridge is implemented by bridges that collect the counts each
+// instance publishes. NodeStats returns one entry per live instance.
+type ClusterBridge interface {
+	NodeStats() ([]types.NodeStats, error)
+}
+
+// DirectoryBridge is implemented by bridges that track which instance
+// holds each client. NodeID names this instance; Locate returns the ID of
+// the instance holding a client; Kick disconnects a client on whichever
+// instance holds it and reports whether one did.
+type DirectoryBridge interface {
+	NodeID() string
+	Locate(clientID string) (string, bool, error)
+	Kick(clientID string) (bool, error)
+}
+
+// PresenceBridge is implemented by bridges that record the presence
+// members each instance holds, so that they can be removed when it dies.
+type PresenceBridge interface {
+	AddMember(channel string, m types.Member) error
+	RemoveMember(channel, clientID string) error
+}
+
+// NodeMonitor is implemented by bridges that detect instances which
+// stopped without shutting down cleanly, reporting the clients and
+// presence members they held.
+type NodeMonitor interface {
+	OnNodeDown(cb func(types.NodeDown))
+}
+
 // Hub manages all WebSocket client connections and channel subscriptions.
 type Hub struct {
 	clients  map[string]*Client
-	channels map[string]map[string]bool // channel -> set of clientIDs
+	channels map[string]map[string]bool         // channel -> set of clientIDs
+	presence map[string]map[string]types.Member // presence channel -> clientID -> member
+
+	shards []*shard
 
-	register   chan *Client
-	unregister chan *Client
-	incoming   chan types.Message
-	broadcast  chan broadcastMsg
-	localCast  chan broadcastMsg // messages from bridge, no re-publish
+	handlers   map[string]handler
+	pool       *workerPool
+	rpc        *rpc
+	authorize  types.SubscribeAuthorizer
+	authz      auth.ChannelAuthorizer
+	onConnect  []func(string)
+	onDisconn  []func(string, types.DisconnectReason)
+	onNodeDown []func(string, []string)
 
-	handlers  map[string]types.MessageHandler
-	onConnect []func(string)
-	onDisconn []func(string)
+	pingInterval time.Duration
+	writeTimeout time.Duration
+	admission    admission
 
-	bridge MessageBridge
-	mu     sync.RWMutex
-	logger zerolog.Logger
-	done   chan struct{}
+	sendBuffer    int
+	resumeGrace   time.Duration
+	replaySize    int
+	sessions      map[string]*session        // detached clientID -> session
+	tokens        map[string]string          // resume token -> detached clientID
+	detached      map[string]map[string]bool // channel -> detached clientIDs
+	history       history
+	slow          SlowConsumerPolicy
+	slowByChannel map[string]SlowConsumerPolicy
+
+	bridge  MessageBridge
+	watchMu sync.Mutex      // serializes Watch/Unwatch calls
+	watched map[string]bool // channels the bridge is watching
+	mu      sync.RWMutex
+	logger  zerolog.Logger
+	ctx     context.Context // cancelled by Stop
+	cancel  context.CancelFunc
+	done    chan struct{}
 }
 
 type broadcastMsg struct {
@@ -40,70 +111,222 @@ type broadcastMsg struct {
 	msg     types.Message
 }
 
+// Default buffer sizes used when no Option overrides them.
+const (
+	DefaultQueueSize  = 256
+	DefaultSendBuffer = 256
+)
+
+type options struct {
+	queueSize      int
+	shards         int
+	sendBuffer     int
+	handlerWorkers int
+	handlerQueue   int
+	handlerTimeout time.Duration
+	rpcTimeout     time.Duration
+}
+
+// Option customizes a Hub at construction time.
+type Option func(*options)
+
+// WithQueueSize sets the capacity of each shard's incoming and broadcast queues.
+func WithQueueSize(n int) Option {
+	return func(o *options) { o.queueSize = n }
+}
+
+// WithShards sets the number of event-loop shards. Zero or less uses
+// GOMAXPROCS.
+func WithShards(n int) Option {
+	return func(o *options) { o.shards = n }
+}
+
+// WithSendBuffer sets the per-client outbound buffer capacity.
+func WithSendBuffer(n int) Option {
+	return func(o *options) { o.sendBuffer = n }
+}
+
+// WithHandlerWorkers sets how many handlers run concurrently.
+func WithHandlerWorkers(n int) Option {
+	return func(o *options) { o.handlerWorkers = n }
+}
+
+// WithHandlerQueue sets how many inbound messages may wait for a handler
+// worker before clients' reads are held back.
+func WithHandlerQueue(n int) Option {
+	return func(o *options) { o.handlerQueue = n }
+}
+
+// WithHandlerTimeout bounds each handler call through its context. Zero
+// disables the timeout.
+func WithHandlerTimeout(d time.Duration) Option {
+	return func(o *options) { o.handlerTimeout = d }
+}
+
+// WithRPCTimeout bounds RPC calls in both directions. Zero disables the
+// timeout.
+func WithRPCTimeout(d time.Duration) Option {
+	return func(o *options) { o.rpcTimeout = d }
+}
+
 // New creates a new Hub instance.
-func New(logger zerolog.Logger) *Hub {
+func New(logger zerolog.Logger, opts ...Option) *Hub {
+	o := options{
+		queueSize:      DefaultQueueSize,
+		sendBuffer:     DefaultSendBuffer,
+		handlerWorkers: DefaultHandlerWorkers,
+		handlerQueue:   DefaultHandlerQueue,
+		rpcTimeout:     DefaultRPCTimeout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.shards <= 0 {
+		o.shards = runtime.GOMAXPROCS(0)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Hub{
-		clients:    make(map[string]*Client),
-		channels:   make(map[string]map[string]bool),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
-		incoming:   make(chan types.Message, 256),
-		broadcast:  make(chan broadcastMsg, 256),
-		localCast:  make(chan broadcastMsg, 256),
-		handlers:   make(map[string]types.MessageHandler),
-		logger:     logger,
-		done:       make(chan struct{}),
+		clients:       make(map[string]*Client),
+		channels:      make(map[string]map[string]bool),
+		presence:      make(map[string]map[string]types.Member),
+		shards:        newShards(o.shards, o.queueSize),
+		handlers:      make(map[string]handler),
+		pool:          newWorkerPool(max(o.handlerWorkers, 1), max(o.handlerQueue, 1), o.handlerTimeout),
+		rpc:           newRPC(o.rpcTimeout),
+		admission:     newAdmission(),
+		sendBuffer:    o.sendBuffer,
+		slow:          SlowConsumerPolicy{Overflow: OverflowDropNewest},
+		slowByChannel: make(map[string]SlowConsumerPolicy),
+		sessions:      make(map[string]*session),
+		tokens:        make(map[string]string),
+		detached:      make(map[string]map[string]bool),
+		history:       newHistory(),
+		watched:       make(map[string]bool),
+		logger:        logger,
+		ctx:           ctx,
+		cancel:        cancel,
+		done:          make(chan struct{}),
 	}
 }
 
 // SetBridge attaches a cross-instance message bridge to the hub.
 // When set, published messages are also forwarded to other instances.
+// Bridges implementing DirectBridge are told about every local client and
+// bridges implementing ChannelBridge about every channel with local interest.
 func (h *Hub) SetBridge(b MessageBridge) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 	h.bridge = b
+	ids := make([]string, 0, len(h.clients))
+	for id := range h.clients {
+		ids = append(ids, id)
+	}
+	channels := make([]string, 0, len(h.channels)+len(h.detached))
+	for ch := range h.channels {
+		channels = append(channels, ch)
+	}
+	for ch := range h.detached {
+		channels = append(channels, ch)
+	}
+	for id := range h.sessions {
+		ids = append(ids, id)
+	}
+	members := make(map[string][]types.Member, len(h.presence))
+	for ch, ms := range h.presence {
+		for _, m := range ms {
+			members[ch] = append(members[ch], m)
+		}
+	}
+	h.mu.Unlock()
+
+	h.watchMu.Lock()
+	h.watched = make(map[string]bool)
+	h.watchMu.Unlock()
+
+	if nm, ok := b.(NodeMonitor); ok {
+		nm.OnNodeDown(h.nodeDown)
+	}
+
+	for _, id := range ids {
+		h.attachClient(id)
+	}
+	for ch, ms := range members {
+		for _, m := range ms {
+			h.listMember(ch, m)
+		}
+	}
+	h.syncInterest(channels...)
+}
+
+// SetHeartbeat configures keep-alive pings and write deadlines for clients
+// registered afterwards. A zero pingInterval disables pings and read
+// deadlines; a zero writeTimeout disables write deadlines.
+func (h *Hub) SetHeartbeat(pingInterval, writeTimeout time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pingInterval = pingInterval
+	h.writeTimeout = writeTimeout
+}
+
+// heartbeat returns the ping interval and write timeout.
+func (h *Hub) heartbeat() (time.Duration, time.Duration) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.pingInterval, h.writeTimeout
 }
 
 // BroadcastToLocal delivers a message from the bridge to local subscribers only.
 // It does not re-publish to Redis, preventing infinite loops.
 func (h *Hub) BroadcastToLocal(msg types.Message) {
-	h.localCast <- broadcastMsg{channel: msg.Channel, msg: msg}
+	h.channelShard(msg.Channel).localCast <- broadcastMsg{channel: msg.Channel, msg: msg}
 }
 
-// Run starts the hub event loop. Call in a goroutine.
+// Run starts the event-loop shards and handler workers and blocks until
+// Stop. Call in a goroutine.
 func (h *Hub) Run() {
-	for {
-		select {
-		case client := <-h.register:
-			h.addClient(client)
-		case client := <-h.unregister:
-			h.removeClient(client)
-		case msg := <-h.incoming:
-			h.handleMessage(msg)
-		case bm := <-h.broadcast:
-			h.publishToBridge(bm.msg)
-			h.broadcastToChannel(bm.channel, bm.msg)
-		case bm := <-h.localCast:
-			h.broadcastToChannel(bm.channel, bm.msg)
-		case <-h.done:
-			return
-		}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.runHistorySweep()
+	}()
+	for range h.pool.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.runWorker()
+		}()
+	}
+	for _, s := range h.shards {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			h.runInbound(s)
+		}()
+		go func() {
+			defer wg.Done()
+			h.runFanout(s)
+		}()
 	}
+	wg.Wait()
 }
 
-// Stop halts the hub event loop.
+// Stop halts the hub event loop and cancels running handlers' contexts.
 func (h *Hub) Stop() {
+	h.cancel()
 	close(h.done)
 }
 
 // Register queues a client for registration.
 func (h *Hub) Register(c *Client) {
-	h.register <- c
+	c.shard.register <- c
 }
 
 // Unregister queues a client for removal.
 func (h *Hub) Unregister(c *Client) {
-	h.unregister <- c
+	c.setReason(types.DisconnectServer)
+	c.shard.unregister <- c
 }
 
 func (h *Hub) addClient(c *Client) {
@@ -112,6 +335,8 @@ func (h *Hub) addClient(c *Client) {
 	h.mu.Unlock()
 
 	h.logger.Info().Str("client_id", c.ID).Msg("client registered")
+	h.attachClient(c.ID)
+	h.startSession(c)
 
 	for _, cb := range h.onConnect {
 		cb(c.ID)
@@ -127,18 +352,40 @@ func (h *Hub) removeClient(c *Client) {
 	delete(h.clients, c.ID)
 
 	// Remove from all channel subscriptions.
+	var joined []string
+	left := make(map[string]types.Member)
 	for ch, subs := range h.channels {
+		if subs[c.ID] {
+			joined = append(joined, ch)
+		}
 		delete(subs, c.ID)
 		if len(subs) == 0 {
 			delete(h.channels, ch)
 		}
+		if m, ok := h.removeMember(ch, c.ID); ok {
+			left[ch] = m
+		}
 	}
 	h.mu.Unlock()
 
 	c.Close()
-	h.logger.Info().Str("client_id", c.ID).Msg("client unregistered")
+	reason := c.disconnectReason()
+	h.logger.Info().
+		Str("client_id", c.ID).
+		Str("reason", string(reason)).
+		Msg("client unregistered")
+	// A detached session stays attached so that direct messages and kicks
+	// from other instances still find it.
+	if !h.detach(c, joined, left, reason) {
+		h.detachClient(c.ID)
+	}
+	h.syncInterest(joined...)
+
+	for ch, m := range left {
+		h.announceLeave(ch, m)
+	}
 
 	for _, cb := range h.onDisconn {
-		cb(c.ID)
+		cb(c.ID, reason)
 	}
 }
@@ -0,0 +1,138 @@
+package hub
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// Connection limit scopes reported in LimitError and rejection stats.
+const (
+	LimitGlobal = "global"
+	LimitIP     = "ip"
+	LimitUser   = "user"
+)
+
+// Limits caps concurrent connections. A zero value disables that cap.
+type Limits struct {
+	MaxConnections int
+	MaxPerIP       int
+	MaxPerUser     int
+}
+
+// LimitError reports which connection cap rejected an admission.
+type LimitError struct {
+	Scope string
+	Limit int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s connection limit of %d reached", e.Scope, e.Limit)
+}
+
+// admission tracks connections admitted before upgrade, so limits hold
+// even while upgrades are still in flight.
+type admission struct {
+	mu       sync.Mutex
+	limits   Limits
+	total    int
+	perIP    map[string]int
+	perUser  map[string]int
+	rejected map[string]uint64
+}
+
+func newAdmission() admission {
+	return admission{
+		perIP:    make(map[string]int),
+		perUser:  make(map[string]int),
+		rejected: make(map[string]uint64),
+	}
+}
+
+// SetLimits configures connection caps enforced by Admit.
+func (h *Hub) SetLimits(l Limits) {
+	h.admission.mu.Lock()
+	defer h.admission.mu.Unlock()
+	h.admission.limits = l
+}
+
+// Admit reserves a connection slot for the given remote IP and user ID
+// (empty for anonymous). It returns a *LimitError when a cap is reached.
+// Every successful Admit must be paired with Release.
+func (h *Hub) Admit(ip, userID string) error {
+	a := &h.admission
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	l := a.limits
+	var err *LimitError
+	switch {
+	case l.MaxConnections > 0 && a.total >= l.MaxConnections:
+		err = &LimitError{Scope: LimitGlobal, Limit: l.MaxConnections}
+	case l.MaxPerIP > 0 && ip != "" && a.perIP[ip] >= l.MaxPerIP:
+		err = &LimitError{Scope: LimitIP, Limit: l.MaxPerIP}
+	case l.MaxPerUser > 0 && userID != "" && a.perUser[userID] >= l.MaxPerUser:
+		err = &LimitError{Scope: LimitUser, Limit: l.MaxPerUser}
+	}
+	if err != nil {
+		a.rejected[err.Scope]++
+		h.logger.Warn().
+			Str("scope", err.Scope).
+			Str("ip", ip).
+			Str("user_id", userID).
+			Msg("connection rejected")
+		return err
+	}
+
+	a.total++
+	if ip != "" {
+		a.perIP[ip]++
+	}
+	if userID != "" {
+		a.perUser[userID]++
+	}
+	return nil
+}
+
+// Release frees a slot reserved by Admit.
+func (h *Hub) Release(ip, userID string) {
+	a := &h.admission
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.total > 0 {
+		a.total--
+	}
+	release(a.perIP, ip)
+	release(a.perUser, userID)
+}
+
+func release(counts map[string]int, key string) {
+	if key == "" {
+		return
+	}
+	if counts[key] <= 1 {
+		delete(counts, key)
+		return
+	}
+	counts[key]--
+}
+
+// Stats returns connection counts, rejection totals and handler pool
+// metrics.
+func (h *Hub) Stats() types.Stats {
+	h.admission.mu.Lock()
+	rejected := make(map[string]uint64, len(h.admission.rejected))
+	for scope, n := range h.admission.rejected {
+		rejected[scope] = n
+	}
+	h.admission.mu.Unlock()
+
+	return types.Stats{
+		Clients:  h.ClientCount(),
+		Channels: len(h.Channels()),
+		Rejected: rejected,
+		Handlers: h.pool.stats(),
+	}
+}
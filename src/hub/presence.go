@@ -0,0 +1,124 @@
+package hub
+
+import (
+	"sort"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// addMember records a client as a member of a presence channel.
+// Caller must hold h.mu for writing.
+func (h *Hub) addMember(channel string, c *Client, info map[string]any) types.Member {
+	userID := c.Identity().UserID
+	if userID == "" {
+		userID = c.ID
+	}
+	m := types.Member{ClientID: c.ID, UserID: userID, Info: info}
+
+	if h.presence[channel] == nil {
+		h.presence[channel] = make(map[string]types.Member)
+	}
+	h.presence[channel][c.ID] = m
+	return m
+}
+
+// removeMember drops a client from a presence channel, reporting whether
+// it was a member. Caller must hold h.mu for writing.
+func (h *Hub) removeMember(channel, clientID string) (types.Member, bool) {
+	members, ok := h.presence[channel]
+	if !ok {
+		return types.Member{}, false
+	}
+	m, ok := members[clientID]
+	if !ok {
+		return types.Member{}, false
+	}
+	delete(members, clientID)
+	if len(members) == 0 {
+		delete(h.presence, channel)
+	}
+	return m, true
+}
+
+// Presence returns the members of a presence channel ordered by client ID.
+func (h *Hub) Presence(channel string) []types.Member {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	members := make([]types.Member, 0, len(h.presence[channel]))
+	for _, m := range h.presence[channel] {
+		members = append(members, m)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].ClientID < members[j].ClientID
+	})
+	return members
+}
+
+// presenceBridge returns the bridge as a PresenceBridge, or nil.
+func (h *Hub) presenceBridge() PresenceBridge {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	pb, _ := h.bridge.(PresenceBridge)
+	return pb
+}
+
+// listMember records a local member with the bridge.
+func (h *Hub) listMember(channel string, m types.Member) {
+	if pb := h.presenceBridge(); pb != nil {
+		if err := pb.AddMember(channel, m); err != nil {
+			h.logger.Error().Err(err).Str("channel", channel).Str("client_id", m.ClientID).Msg("bridge add member failed")
+		}
+	}
+}
+
+// unlistMember withdraws a local member from the bridge.
+func (h *Hub) unlistMember(channel, clientID string) {
+	if pb := h.presenceBridge(); pb != nil {
+		if err := pb.RemoveMember(channel, clientID); err != nil {
+			h.logger.Error().Err(err).Str("channel", channel).Str("client_id", clientID).Msg("bridge remove member failed")
+		}
+	}
+}
+
+// announceJoin records the new member with the bridge, sends it the
+// member list and sends member_added to everyone else on the channel.
+func (h *Hub) announceJoin(channel string, m types.Member) {
+	h.listMember(channel, m)
+	h.SendToClient(m.ClientID, types.Message{
+		Channel:   channel,
+		Event:     types.EventMembers,
+		Data:      map[string]any{"members": h.Presence(channel)},
+		Timestamp: time.Now(),
+	})
+	h.broadcastToChannelExcept(channel, m.ClientID, types.Message{
+		Channel:   channel,
+		Event:     types.EventMemberAdded,
+		Data:      memberData(m),
+		Timestamp: time.Now(),
+	})
+}
+
+// announceLeave withdraws the member from the bridge and sends
+// member_removed to the remaining subscribers.
+func (h *Hub) announceLeave(channel string, m types.Member) {
+	h.unlistMember(channel, m.ClientID)
+	h.broadcastToChannel(channel, types.Message{
+		Channel:   channel,
+		Event:     types.EventMemberRemoved,
+		Data:      memberData(m),
+		Timestamp: time.Now(),
+	})
+}
+
+func memberData(m types.Member) map[string]any {
+	data := map[string]any{
+		"client_id": m.ClientID,
+		"user_id":   m.UserID,
+	}
+	if m.Info != nil {
+		data["info"] = m.Info
+	}
+	return data
+}
@@ -1,50 +1,96 @@
 package hub
 
 import (
+	"fmt"
+
+	"github.com/orchestra-mcp/socket/src/auth"
 	"github.com/orchestra-mcp/socket/src/types"
 )
 
 func (h *Hub) handleMessage(msg types.Message) {
+	if msg.Channel == types.SystemChannel {
+		h.handleControl(msg)
+		return
+	}
+
 	h.mu.RLock()
 	handler, ok := h.handlers[msg.Channel]
+	client := h.clients[msg.ClientID]
 	h.mu.RUnlock()
 
 	if !ok {
 		h.logger.Debug().Str("channel", msg.Channel).Msg("no handler")
 		return
 	}
-	if err := handler(msg.ClientID, msg); err != nil {
-		h.logger.Error().Err(err).Str("channel", msg.Channel).Msg("handler error")
+	if client == nil {
+		return
+	}
+	if err := h.Authorize(msg.ClientID, msg.Channel, auth.ActionInvoke); err != nil {
+		h.replyError(msg, msg.Channel, err)
+		return
 	}
+	h.dispatch(handlerJob{client: client, msg: msg, handler: handler})
 }
 
 func (h *Hub) broadcastToChannel(channel string, msg types.Message) {
-	h.mu.RLock()
-	subs, ok := h.channels[channel]
-	if !ok {
-		h.mu.RUnlock()
+	h.broadcastToChannelExcept(channel, "", msg)
+}
+
+// broadcastToChannelExcept delivers msg to every subscriber but except.
+// The message is encoded once and the frame shared by all subscribers.
+// Detached sessions subscribed to channel buffer msg for replay.
+func (h *Hub) broadcastToChannelExcept(channel, except string, msg types.Message) {
+	clients := h.subscribers(channel, except, msg)
+	if len(clients) == 0 {
 		return
 	}
-	// Copy subscriber IDs to avoid holding lock during sends.
-	ids := make([]string, 0, len(subs))
-	for id := range subs {
-		ids = append(ids, id)
+	frame, err := types.NewFrame(msg)
+	if err != nil {
+		h.logger.Warn().Err(err).Str("channel", channel).Msg("failed to encode broadcast")
+		return
+	}
+	policy := h.slowPolicy(channel)
+	for _, c := range clients {
+		_ = h.deliverFrame(c, msg, frame, policy)
+	}
+}
+
+// subscribers returns the live subscribers of channel but except. When
+// detached sessions follow channel too, msg is buffered for them under
+// the same lock, so a session resuming concurrently receives msg either
+// in its replay or live, never neither.
+func (h *Hub) subscribers(channel, except string, msg types.Message) []*Client {
+	h.mu.RLock()
+	if len(h.detached[channel]) == 0 {
+		defer h.mu.RUnlock()
+		return h.subscribersLocked(channel, except)
 	}
 	h.mu.RUnlock()
 
-	for _, id := range ids {
-		h.mu.RLock()
-		client, exists := h.clients[id]
-		h.mu.RUnlock()
-		if !exists {
-			continue
-		}
-		select {
-		case client.Send <- msg:
-		default:
-			h.logger.Warn().Str("client_id", id).Msg("send buffer full, dropping")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.bufferDetachedLocked(channel, msg)
+	return h.subscribersLocked(channel, except)
+}
+
+// subscribersLocked snapshots a channel's clients. Caller holds h.mu.
+func (h *Hub) subscribersLocked(channel, except string) []*Client {
+	subs := h.channels[channel]
+	clients := make([]*Client, 0, len(subs))
+	for id := range subs {
+		if c, ok := h.clients[id]; ok && id != except {
+			clients = append(clients, c)
 		}
 	}
+	return clients
+}
+
+// bridgeAvailable reports whether a connected bridge is attached.
+func (h *Hub) bridgeAvailable() bool {
+	h.mu.RLock()
+	b := h.bridge
+	h.mu.RUnlock()
+	return b != nil && b.Available()
 }
 
 // publishToBridge forwards a message to the bridge if one is attached.
@@ -61,34 +107,173 @@ func (h *Hub) publishToBridge(msg types.Message) {
 	}
 }
 
+// directBridge returns the attached bridge if it routes direct messages.
+func (h *Hub) directBridge() DirectBridge {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	db, _ := h.bridge.(DirectBridge)
+	return db
+}
+
+// attachClient announces a local client to the bridge.
+func (h *Hub) attachClient(clientID string) {
+	if db := h.directBridge(); db != nil {
+		if err := db.Attach(clientID); err != nil {
+			h.logger.Error().Err(err).Str("client_id", clientID).Msg("bridge attach failed")
+		}
+	}
+}
+
+// detachClient withdraws a local client from the bridge.
+func (h *Hub) detachClient(clientID string) {
+	if db := h.directBridge(); db != nil {
+		if err := db.Detach(clientID); err != nil {
+			h.logger.Error().Err(err).Str("client_id", clientID).Msg("bridge detach failed")
+		}
+	}
+}
+
+// syncInterest reconciles the bridge's channel subscriptions with local
+// interest for the given channels. Computing and applying each change
+// under watchMu keeps concurrent callers from reordering Watch/Unwatch.
+func (h *Hub) syncInterest(channels ...string) {
+	h.mu.RLock()
+	cb, _ := h.bridge.(ChannelBridge)
+	h.mu.RUnlock()
+	if cb == nil {
+		return
+	}
+
+	h.watchMu.Lock()
+	defer h.watchMu.Unlock()
+	for _, ch := range channels {
+		h.mu.RLock()
+		want := len(h.channels[ch]) > 0 || len(h.detached[ch]) > 0
+		h.mu.RUnlock()
+		if want == h.watched[ch] {
+			continue
+		}
+
+		var err error
+		if want {
+			err = cb.Watch(ch)
+		} else {
+			err = cb.Unwatch(ch)
+		}
+		if err != nil {
+			h.logger.Error().Err(err).Str("channel", ch).Bool("watch", want).Msg("bridge interest update failed")
+			continue
+		}
+		if want {
+			h.watched[ch] = true
+		} else {
+			delete(h.watched, ch)
+		}
+	}
+}
+
 // Publish sends a message to all subscribers of a channel.
+// Messages attributed to a client (non-empty ClientID) are checked against
+// the channel authorizer and dropped if the client may not publish.
 func (h *Hub) Publish(channel string, msg types.Message) {
-	h.broadcast <- broadcastMsg{channel: channel, msg: msg}
+	if msg.ClientID != "" {
+		if err := h.Authorize(msg.ClientID, channel, auth.ActionPublish); err != nil {
+			h.logger.Warn().Err(err).
+				Str("client_id", msg.ClientID).
+				Str("channel", channel).
+				Msg("publish rejected")
+			return
+		}
+	}
+	h.channelShard(channel).broadcast <- broadcastMsg{channel: channel, msg: msg}
 }
 
 // Subscribe adds a client to a channel.
 func (h *Hub) Subscribe(channel, clientID string) bool {
+	return h.SubscribeClient(channel, clientID) == nil
+}
+
+// SubscribeClient adds a client to a channel after consulting the channel
+// authorizer, reporting why the subscription failed.
+func (h *Hub) SubscribeClient(channel, clientID string) error {
+	return h.SubscribeMember(channel, clientID, nil)
+}
+
+// SubscribeOptions customizes a subscription.
+type SubscribeOptions struct {
+	// Member is the metadata recorded for the client on presence channels.
+	Member map[string]any
+	// History delivers up to this many retained messages to the client
+	// right after it joins.
+	History int
+}
+
+// SubscribeMember is SubscribeClient with member metadata. On presence
+// channels the client is recorded as a member carrying info, receives the
+// current member list, and existing subscribers are sent member_added.
+// info is ignored on other channels.
+func (h *Hub) SubscribeMember(channel, clientID string, info map[string]any) error {
+	return h.SubscribeWith(channel, clientID, SubscribeOptions{Member: info})
+}
+
+// SubscribeWith adds a client to a channel with the given options.
+func (h *Hub) SubscribeWith(channel, clientID string, opts SubscribeOptions) error {
+	if err := h.Authorize(clientID, channel, auth.ActionSubscribe); err != nil {
+		return err
+	}
+	return h.subscribe(channel, clientID, opts)
+}
+
+// subscribe adds a client to a channel the caller already authorized.
+func (h *Hub) subscribe(channel, clientID string, opts SubscribeOptions) error {
 	h.mu.Lock()
-	defer h.mu.Unlock()
+	client, ok := h.clients[clientID]
+	if !ok {
+		h.mu.Unlock()
+		return fmt.Errorf("client %s not found", clientID)
+	}
+	joined, member, announce := h.joinLocked(channel, client, opts.Member)
+	h.mu.Unlock()
 
-	if _, ok := h.clients[clientID]; !ok {
-		return false
+	if joined {
+		h.syncInterest(channel)
+	}
+	if joined && opts.History > 0 {
+		for _, msg := range h.History(channel, HistoryOptions{Limit: opts.History}) {
+			_ = h.deliver(client, msg, h.slowPolicy(channel))
+		}
+	}
+	if announce {
+		h.announceJoin(channel, member)
 	}
+	return nil
+}
+
+// joinLocked adds client to channel and, on presence channels, records it
+// as a member. It reports whether the client was new to the channel and,
+// if so, the member to announce. Caller holds h.mu for writing.
+func (h *Hub) joinLocked(channel string, client *Client, info map[string]any) (joined bool, member types.Member, announce bool) {
 	if h.channels[channel] == nil {
 		h.channels[channel] = make(map[string]bool)
 	}
-	h.channels[channel][clientID] = true
-	h.clients[clientID].AddChannel(channel)
-	return true
+	joined = !h.channels[channel][client.ID]
+	h.channels[channel][client.ID] = true
+	client.AddChannel(channel)
+
+	announce = joined && auth.IsPresence(channel)
+	if announce {
+		member = h.addMember(channel, client, info)
+	}
+	return joined, member, announce
 }
 
-// Unsubscribe removes a client from a channel.
+// Unsubscribe removes a client from a channel, reporting whether it was
+// subscribed.
 func (h *Hub) Unsubscribe(channel, clientID string) bool {
 	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	subs, ok := h.channels[channel]
-	if !ok {
+	subs := h.channels[channel]
+	if !subs[clientID] {
+		h.mu.Unlock()
 		return false
 	}
 	delete(subs, clientID)
@@ -98,21 +283,18 @@ func (h *Hub) Unsubscribe(channel, clientID string) bool {
 	if c, ok := h.clients[clientID]; ok {
 		c.RemoveChannel(channel)
 	}
+	member, left := h.removeMember(channel, clientID)
+	h.mu.Unlock()
+
+	h.syncInterest(channel)
+	if left {
+		h.announceLeave(channel, member)
+	}
 	return true
 }
 
 // SendToClient sends a message directly to a specific client.
+// Use Send to learn why delivery failed.
 func (h *Hub) SendToClient(clientID string, msg types.Message) bool {
-	h.mu.RLock()
-	client, ok := h.clients[clientID]
-	h.mu.RUnlock()
-	if !ok {
-		return false
-	}
-	select {
-	case client.Send <- msg:
-		return true
-	default:
-		return false
-	}
+	return h.Send(clientID, msg) == nil
 }
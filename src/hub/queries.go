@@ -1,14 +1,63 @@
 package hub
 
 import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/auth"
 	"github.com/orchestra-mcp/socket/src/types"
 )
 
 // RegisterHandler registers a handler for a channel.
 func (h *Hub) RegisterHandler(channel string, handler types.MessageHandler) {
+	h.RegisterContextHandler(channel, func(_ context.Context, clientID string, msg types.Message) error {
+		return handler(clientID, msg)
+	})
+}
+
+// RegisterContextHandler registers a handler for a channel that receives
+// a context bounded by the handler timeout. Its errors are only logged;
+// see RegisterRequestHandler to answer the client.
+func (h *Hub) RegisterContextHandler(channel string, fn types.ContextHandler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handlers[channel] = handler{fn: func(ctx context.Context, req *Request) error {
+		return fn(ctx, req.ClientID, req.Message)
+	}}
+}
+
+// SetSubscribeAuthorizer installs the hook consulted before a client joins
+// a channel through a subscribe control frame. A nil hook allows all.
+func (h *Hub) SetSubscribeAuthorizer(fn types.SubscribeAuthorizer) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	h.handlers[channel] = handler
+	h.authorize = fn
+}
+
+// SetChannelAuthorizer installs the policy consulted for subscribe, publish
+// and handler invocation. A nil authorizer allows everything.
+func (h *Hub) SetChannelAuthorizer(a auth.ChannelAuthorizer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.authz = a
+}
+
+// Authorize checks whether a client may perform action on channel.
+func (h *Hub) Authorize(clientID, channel string, action auth.Action) error {
+	h.mu.RLock()
+	authz := h.authz
+	client, ok := h.clients[clientID]
+	h.mu.RUnlock()
+
+	if authz == nil {
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("client %s not found", clientID)
+	}
+	return authz.Authorize(client.Info(), channel, action)
 }
 
 // OnConnection registers a callback for new connections.
@@ -18,8 +67,9 @@ func (h *Hub) OnConnection(cb func(string)) {
 	h.onConnect = append(h.onConnect, cb)
 }
 
-// OnDisconnection registers a callback for disconnections.
-func (h *Hub) OnDisconnection(cb func(string)) {
+// OnDisconnection registers a callback for disconnections. The callback
+// receives the reason the client left.
+func (h *Hub) OnDisconnection(cb func(string, types.DisconnectReason)) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	h.onDisconn = append(h.onDisconn, cb)
@@ -65,3 +115,37 @@ func (h *Hub) ClientCount() int {
 	defer h.mu.RUnlock()
 	return len(h.clients)
 }
+
+// ClusterStats aggregates client and subscriber counts across every
+// instance reporting through the bridge. Without a bridge that collects
+// them, the cluster is this instance alone.
+func (h *Hub) ClusterStats() (types.ClusterStats, error) {
+	h.mu.RLock()
+	cb, ok := h.bridge.(ClusterBridge)
+	h.mu.RUnlock()
+
+	var nodes []types.NodeStats
+	if ok {
+		var err error
+		if nodes, err = cb.NodeStats(); err != nil {
+			return types.ClusterStats{}, fmt.Errorf("cluster stats: %w", err)
+		}
+	} else {
+		nodes = []types.NodeStats{{
+			InstanceID: "local",
+			Clients:    h.ClientCount(),
+			Channels:   h.Channels(),
+			UpdatedAt:  time.Now(),
+		}}
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].InstanceID < nodes[j].InstanceID })
+	stats := types.ClusterStats{Nodes: nodes, Channels: make(map[string]int)}
+	for _, n := range nodes {
+		stats.Clients += n.Clients
+		for ch, subs := range n.Channels {
+			stats.Channels[ch] += subs
+		}
+	}
+	return stats, nil
+}
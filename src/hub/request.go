@@ -0,0 +1,113 @@
+package hub
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// Error codes sent in handler error frames.
+const (
+	CodeHandlerError = "handler_error" // the handler returned a plain error
+	CodeTimeout      = "timeout"       // the handler outlived its timeout
+)
+
+// HandlerError is an error a handler returns, or passes to ReplyError, to
+// choose the code and message the client receives.
+type HandlerError struct {
+	Code    string
+	Message string
+}
+
+func (e *HandlerError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// Request is an inbound message delivered to a RequestHandler.
+type Request struct {
+	ClientID string
+	Identity types.Identity
+	Message  types.Message
+
+	hub    *Hub
+	failed atomic.Bool // an error frame was sent
+}
+
+// RequestHandler handles inbound messages on a channel. Its context is
+// cancelled when the client disconnects or the hub stops and expires
+// after the handler timeout. A returned error is sent to the client as an
+// error frame, unless ReplyError already answered the request.
+type RequestHandler func(ctx context.Context, req *Request) error
+
+// handler is a registered channel handler. Errors from request handlers
+// are sent back to the client; errors from other handlers are only logged.
+type handler struct {
+	fn      RequestHandler
+	replies bool
+}
+
+// RegisterRequestHandler registers a handler for a channel that can reply
+// to the sending client.
+func (h *Hub) RegisterRequestHandler(channel string, fn RequestHandler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handlers[channel] = handler{fn: fn, replies: true}
+}
+
+// Reply sends data to the requesting client as a "reply" event on the
+// request's channel, carrying the request's ID. It may be called more
+// than once.
+func (r *Request) Reply(data map[string]any) error {
+	return r.hub.Send(r.ClientID, types.Message{
+		Channel:   r.Message.Channel,
+		Event:     types.EventReply,
+		ID:        r.Message.ID,
+		Data:      data,
+		Timestamp: time.Now(),
+	})
+}
+
+// ReplyError sends err to the requesting client as an error frame on the
+// system channel, carrying the request's ID. A *HandlerError sets the
+// code; other errors use CodeHandlerError.
+func (r *Request) ReplyError(err error) error {
+	r.failed.Store(true)
+	code, message := CodeHandlerError, err.Error()
+	if he, ok := err.(*HandlerError); ok {
+		code, message = he.Code, he.Message
+	}
+	return r.hub.Send(r.ClientID, errorFrame(r.Message, r.Message.Channel, code, message))
+}
+
+// fail answers the request with err unless it was already answered with
+// an error.
+func (r *Request) fail(err error) {
+	if r.failed.Load() {
+		return
+	}
+	if sendErr := r.ReplyError(err); sendErr != nil {
+		r.hub.logger.Debug().Err(sendErr).Str("client_id", r.ClientID).Msg("error frame not delivered")
+	}
+}
+
+// errorFrame builds an error frame answering req. Code is omitted when
+// empty.
+func errorFrame(req types.Message, channel, code, message string) types.Message {
+	data := map[string]any{
+		"event":   req.Event,
+		"channel": channel,
+		"error":   message,
+	}
+	if code != "" {
+		data["code"] = code
+	}
+	return types.Message{
+		Channel:   types.SystemChannel,
+		Event:     types.EventError,
+		ID:        req.ID,
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+}
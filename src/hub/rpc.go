@@ -0,0 +1,257 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/auth"
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// Error codes sent in RPC results, besides CodeHandlerError and
+// CodeTimeout.
+const (
+	CodeInvalidRequest = "invalid_request"  // rpc frame without id or method
+	CodeMethodNotFound = "method_not_found" // no method registered under the name
+	CodeInvalidParams  = "invalid_params"   // params do not decode into the method's type
+	CodeForbidden      = "forbidden"        // the channel authorizer rejected the call
+)
+
+// DefaultRPCTimeout bounds RPC calls when no Option overrides it.
+const DefaultRPCTimeout = 10 * time.Second
+
+// ErrCallAborted is returned by Call when the client disconnects before
+// answering.
+var ErrCallAborted = errors.New("client disconnected before answering")
+
+// RPCChannelPrefix prefixes method names when calls are checked by the
+// channel authorizer, so policies can match patterns like "rpc:admin.*".
+const RPCChannelPrefix = "rpc:"
+
+// Call is an RPC invocation received from a client.
+type Call struct {
+	ID       string
+	ClientID string
+	Identity types.Identity
+	Method   string
+	Params   any // decoded JSON
+}
+
+// Bind decodes the call's params into v. Errors are *HandlerError with
+// CodeInvalidParams, so methods can return them as they are.
+func (c *Call) Bind(v any) error {
+	data, err := json.Marshal(c.Params)
+	if err == nil {
+		err = json.Unmarshal(data, v)
+	}
+	if err != nil {
+		return &HandlerError{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// Method handles an RPC call. Its result must encode as JSON. Returning a
+// *HandlerError chooses the error code sent to the client.
+type Method func(ctx context.Context, call *Call) (any, error)
+
+// rpc holds registered methods and server calls awaiting a client.
+type rpc struct {
+	timeout time.Duration
+	nextID  atomic.Uint64
+
+	mu      sync.Mutex
+	methods map[string]Method
+	pending map[string]pendingCall // call ID -> waiter
+}
+
+// pendingCall is a server call waiting for its client's rpc_result.
+type pendingCall struct {
+	clientID string
+	result   chan types.Message
+}
+
+func newRPC(timeout time.Duration) *rpc {
+	return &rpc{
+		timeout: timeout,
+		methods: make(map[string]Method),
+		pending: make(map[string]pendingCall),
+	}
+}
+
+// RegisterMethod registers an RPC method clients can call with an "rpc"
+// frame on the system channel.
+func (h *Hub) RegisterMethod(name string, fn Method) {
+	h.rpc.mu.Lock()
+	defer h.rpc.mu.Unlock()
+	h.rpc.methods[name] = fn
+}
+
+// handleRPC validates an rpc frame and queues its method on the handler
+// worker pool.
+func (h *Hub) handleRPC(msg types.Message) {
+	name, _ := msg.Data["method"].(string)
+	if msg.ID == "" || name == "" {
+		h.rpcResult(msg, name, nil, &HandlerError{Code: CodeInvalidRequest, Message: "rpc requires id and method"})
+		return
+	}
+
+	h.rpc.mu.Lock()
+	fn, ok := h.rpc.methods[name]
+	h.rpc.mu.Unlock()
+	if !ok {
+		h.rpcResult(msg, name, nil, &HandlerError{Code: CodeMethodNotFound, Message: "unknown method " + name})
+		return
+	}
+	if err := h.Authorize(msg.ClientID, RPCChannelPrefix+name, auth.ActionInvoke); err != nil {
+		h.rpcResult(msg, name, nil, &HandlerError{Code: CodeForbidden, Message: err.Error()})
+		return
+	}
+
+	h.mu.RLock()
+	client := h.clients[msg.ClientID]
+	h.mu.RUnlock()
+	if client == nil {
+		return
+	}
+	h.dispatch(handlerJob{client: client, msg: msg, handler: handler{fn: h.methodHandler(name, fn)}})
+}
+
+// methodHandler adapts a Method to the worker pool. The call's deadline
+// is the RPC timeout, shortened by the frame's "timeout_ms" if given.
+func (h *Hub) methodHandler(name string, fn Method) RequestHandler {
+	return func(ctx context.Context, req *Request) error {
+		timeout := h.rpc.timeout
+		if ms, ok := req.Message.Data["timeout_ms"].(float64); ok && ms > 0 {
+			if d := time.Duration(ms) * time.Millisecond; timeout <= 0 || d < timeout {
+				timeout = d
+			}
+		}
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		result, err := fn(ctx, &Call{
+			ID:       req.Message.ID,
+			ClientID: req.ClientID,
+			Identity: req.Identity,
+			Method:   name,
+			Params:   req.Message.Data["params"],
+		})
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			result, err = nil, &HandlerError{Code: CodeTimeout, Message: "call timed out"}
+		case ctx.Err() != nil:
+			return ctx.Err() // client gone or hub stopping
+		}
+		h.rpcResult(req.Message, name, result, err)
+		return err
+	}
+}
+
+// rpcResult answers an rpc frame with a result or an error.
+func (h *Hub) rpcResult(req types.Message, name string, result any, err error) {
+	data := map[string]any{"method": name}
+	if err != nil {
+		code, message := CodeHandlerError, err.Error()
+		if he, ok := err.(*HandlerError); ok {
+			code, message = he.Code, he.Message
+		}
+		data["code"], data["error"] = code, message
+	} else {
+		data["result"] = result
+	}
+	if sendErr := h.Send(req.ClientID, types.Message{
+		ID:        req.ID,
+		Channel:   types.SystemChannel,
+		Event:     types.EventRPCResult,
+		Data:      data,
+		Timestamp: time.Now(),
+	}); sendErr != nil {
+		h.logger.Debug().Err(sendErr).Str("client_id", req.ClientID).Msg("rpc result not delivered")
+	}
+}
+
+// Call invokes a method on a client connected to this instance and waits
+// for its rpc_result. The call ends with ctx, after the RPC timeout, or
+// with ErrCallAborted when the client disconnects. An error answered by
+// the client is returned as a *HandlerError.
+func (h *Hub) Call(ctx context.Context, clientID, method string, params any) (any, error) {
+	h.mu.RLock()
+	client, ok := h.clients[clientID]
+	policy := h.slow
+	h.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrClientNotFound, clientID)
+	}
+	if h.rpc.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.rpc.timeout)
+		defer cancel()
+	}
+
+	id := "srv-" + strconv.FormatUint(h.rpc.nextID.Add(1), 10)
+	result := make(chan types.Message, 1)
+	h.rpc.mu.Lock()
+	h.rpc.pending[id] = pendingCall{clientID: clientID, result: result}
+	h.rpc.mu.Unlock()
+	defer func() {
+		h.rpc.mu.Lock()
+		delete(h.rpc.pending, id)
+		h.rpc.mu.Unlock()
+	}()
+
+	err := h.deliver(client, types.Message{
+		ID:        id,
+		Channel:   types.SystemChannel,
+		Event:     types.EventRPC,
+		Data:      map[string]any{"method": method, "params": params},
+		Timestamp: time.Now(),
+	}, policy)
+	if err != nil {
+		return nil, fmt.Errorf("call %s on %s: %w", method, clientID, err)
+	}
+
+	select {
+	case res := <-result:
+		code, _ := res.Data["code"].(string)
+		message, _ := res.Data["error"].(string)
+		if code != "" || message != "" {
+			if code == "" {
+				code = CodeHandlerError
+			}
+			return nil, &HandlerError{Code: code, Message: message}
+		}
+		return res.Data["result"], nil
+	case <-client.ctx.Done():
+		return nil, fmt.Errorf("call %s on %s: %w", method, clientID, ErrCallAborted)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("call %s on %s: %w", method, clientID, ctx.Err())
+	}
+}
+
+// resolveCall hands a client's rpc_result to the server call awaiting it.
+// Results for unknown calls, or from another client, are ignored.
+func (h *Hub) resolveCall(msg types.Message) {
+	h.rpc.mu.Lock()
+	p, ok := h.rpc.pending[msg.ID]
+	if ok && p.clientID == msg.ClientID {
+		delete(h.rpc.pending, msg.ID)
+	} else {
+		ok = false
+	}
+	h.rpc.mu.Unlock()
+
+	if !ok {
+		h.logger.Debug().Str("client_id", msg.ClientID).Str("id", msg.ID).Msg("unexpected rpc result")
+		return
+	}
+	p.result <- msg
+}
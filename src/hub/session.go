@@ -0,0 +1,280 @@
+package hub
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/auth"
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// session holds the state of a disconnected client during the resume
+// grace period: its subscriptions and the messages it missed.
+type session struct {
+	token    string
+	userID   string
+	channels []string
+	members  map[string]map[string]any // presence channel -> member info
+	buffer   []types.Message
+	dropped  int
+	claimed  bool
+	timer    *time.Timer
+}
+
+// SetResume enables resumable sessions. After an unexpected disconnect the
+// client's subscriptions are kept for grace and up to bufferSize missed
+// messages are buffered; reconnecting with the session token restores the
+// same client ID, subscriptions, and replays the buffer. A zero grace
+// disables resumption.
+func (h *Hub) SetResume(grace time.Duration, bufferSize int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.resumeGrace = grace
+	h.replaySize = bufferSize
+}
+
+// ClaimSession validates a resume token and reserves its session. It
+// returns the client ID to reuse for the new connection. userID must
+// match the identity the session was created with.
+func (h *Hub) ClaimSession(token, userID string) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	id, ok := h.tokens[token]
+	if !ok {
+		return "", false
+	}
+	s := h.sessions[id]
+	if s == nil || s.claimed || s.userID != userID {
+		return "", false
+	}
+	s.claimed = true
+	delete(h.tokens, token)
+	return id, true
+}
+
+// startSession issues a fresh token to a newly registered client and, if
+// the client claimed a detached session, replays missed messages and
+// restores its subscriptions.
+func (h *Hub) startSession(c *Client) {
+	h.mu.Lock()
+	if h.resumeGrace <= 0 {
+		h.mu.Unlock()
+		return
+	}
+	s := h.sessions[c.ID]
+	if s != nil && s.claimed {
+		// The session keeps buffering until resume swaps it out.
+		if s.timer != nil {
+			s.timer.Stop()
+		}
+	} else {
+		s = nil
+	}
+	data := map[string]any{"resumed": s != nil}
+	if s != nil {
+		data["channels"] = s.channels
+		data["replayed"] = len(s.buffer)
+		data["dropped"] = s.dropped
+	}
+	h.mu.Unlock()
+
+	token := newToken()
+	c.setToken(token)
+	data["client_id"] = c.ID
+	data["token"] = token
+	h.SendToClient(c.ID, types.Message{
+		Channel:   types.SystemChannel,
+		Event:     types.EventSession,
+		Data:      data,
+		Timestamp: time.Now(),
+	})
+	if s != nil {
+		h.resume(c, s)
+	}
+}
+
+// resume replays a claimed session's buffer, then swaps the session for
+// live subscriptions under one lock. Broadcasts run on other goroutines
+// and keep buffering into the session until the swap, so none is lost or
+// delivered ahead of the replay.
+func (h *Hub) resume(c *Client, s *session) {
+	var allowed []string
+	for _, ch := range s.channels {
+		if err := h.Authorize(c.ID, ch, auth.ActionSubscribe); err != nil {
+			h.logger.Warn().Err(err).
+				Str("client_id", c.ID).
+				Str("channel", ch).
+				Msg("resubscribe on resume failed")
+			continue
+		}
+		allowed = append(allowed, ch)
+	}
+
+	type join struct {
+		channel string
+		member  types.Member
+	}
+	var joins []join
+	replay := SlowConsumerPolicy{Overflow: OverflowBlock, Timeout: time.Second}
+	replayed, failed := 0, false
+	for {
+		h.mu.Lock()
+		batch := s.buffer
+		s.buffer = nil
+		if len(batch) > 0 && !failed {
+			h.mu.Unlock()
+			for _, msg := range batch {
+				if err := h.deliver(c, msg, replay); err != nil {
+					failed = true
+					break
+				}
+				replayed++
+			}
+			continue
+		}
+
+		if h.sessions[c.ID] == s {
+			h.dropSession(c.ID, s)
+		}
+		if h.clients[c.ID] == c {
+			for _, ch := range allowed {
+				if _, m, announce := h.joinLocked(ch, c, s.members[ch]); announce {
+					joins = append(joins, join{ch, m})
+				}
+			}
+		}
+		h.mu.Unlock()
+		break
+	}
+
+	h.syncInterest(s.channels...)
+	for _, j := range joins {
+		h.announceJoin(j.channel, j.member)
+	}
+	h.logger.Info().
+		Str("client_id", c.ID).
+		Int("replayed", replayed).
+		Msg("session resumed")
+}
+
+// detach keeps a disconnected client's state for the grace period and
+// reports whether it did. Explicit server removals and slow-consumer
+// evictions are not resumable.
+func (h *Hub) detach(c *Client, channels []string, left map[string]types.Member, reason types.DisconnectReason) bool {
+	if reason == types.DisconnectServer || reason == types.DisconnectSlowConsumer {
+		return false
+	}
+	token := c.sessionToken()
+	if token == "" {
+		return false
+	}
+
+	s := &session{
+		token:    token,
+		userID:   c.Identity().UserID,
+		channels: channels,
+		members:  make(map[string]map[string]any, len(left)),
+	}
+	for ch, m := range left {
+		s.members[ch] = m.Info
+	}
+	// Messages still queued for the old connection were never written.
+	for msg := range c.Send {
+		s.buffer = append(s.buffer, msg)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.resumeGrace <= 0 {
+		return false
+	}
+	s.trim(h.replaySize)
+	h.sessions[c.ID] = s
+	h.tokens[token] = c.ID
+	for _, ch := range channels {
+		if h.detached[ch] == nil {
+			h.detached[ch] = make(map[string]bool)
+		}
+		h.detached[ch][c.ID] = true
+	}
+	id := c.ID
+	s.timer = time.AfterFunc(h.resumeGrace, func() { h.expireSession(id, s) })
+	return true
+}
+
+// expireSession discards a session whose grace period ran out and
+// withdraws the client from the bridge, unless it reconnected meanwhile.
+func (h *Hub) expireSession(id string, s *session) {
+	h.mu.Lock()
+	if h.sessions[id] != s {
+		h.mu.Unlock()
+		return
+	}
+	h.dropSession(id, s)
+	_, live := h.clients[id]
+	h.mu.Unlock()
+
+	if !live {
+		h.detachClient(id)
+	}
+	h.syncInterest(s.channels...)
+	h.logger.Debug().Str("client_id", id).Msg("session expired")
+}
+
+// dropSession removes a session and its indexes. Caller holds h.mu.
+func (h *Hub) dropSession(id string, s *session) {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	delete(h.sessions, id)
+	delete(h.tokens, s.token)
+	for _, ch := range s.channels {
+		if subs := h.detached[ch]; subs != nil {
+			delete(subs, id)
+			if len(subs) == 0 {
+				delete(h.detached, ch)
+			}
+		}
+	}
+}
+
+// bufferDetachedLocked appends a channel message to every detached
+// session subscribed to it. Caller holds h.mu for writing.
+func (h *Hub) bufferDetachedLocked(channel string, msg types.Message) {
+	for id := range h.detached[channel] {
+		if s := h.sessions[id]; s != nil {
+			s.buffer = append(s.buffer, msg)
+			s.trim(h.replaySize)
+		}
+	}
+}
+
+// bufferDirect stores a direct message for a detached client, reporting
+// whether such a session exists.
+func (h *Hub) bufferDirect(clientID string, msg types.Message) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.sessions[clientID]
+	if s == nil {
+		return false
+	}
+	s.buffer = append(s.buffer, msg)
+	s.trim(h.replaySize)
+	return true
+}
+
+// trim keeps the newest max messages.
+func (s *session) trim(max int) {
+	if over := len(s.buffer) - max; over > 0 {
+		s.buffer = append(s.buffer[:0], s.buffer[over:]...)
+		s.dropped += over
+	}
+}
+
+func newToken() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,89 @@
+package hub
+
+import "github.com/orchestra-mcp/socket/src/types"
+
+// shard is one partition of the hub's event loop. Registration, removal
+// and inbound messages are routed by client ID to the shard's inbound
+// goroutine, which hands messages to the handler worker pool; broadcasts
+// are routed by channel to its fan-out goroutine. Events of one client,
+// and messages of one channel, are therefore processed in order.
+type shard struct {
+	register   chan *Client
+	unregister chan *Client
+	incoming   chan types.Message
+	broadcast  chan broadcastMsg
+	localCast  chan broadcastMsg // messages from bridge, no re-publish
+}
+
+func newShards(n, queueSize int) []*shard {
+	shards := make([]*shard, n)
+	for i := range shards {
+		shards[i] = &shard{
+			register:   make(chan *Client),
+			unregister: make(chan *Client),
+			incoming:   make(chan types.Message, queueSize),
+			broadcast:  make(chan broadcastMsg, queueSize),
+			localCast:  make(chan broadcastMsg, queueSize),
+		}
+	}
+	return shards
+}
+
+// shardIndex hashes key (FNV-1a) onto one of n shards.
+func shardIndex(key string, n int) int {
+	if n == 1 {
+		return 0
+	}
+	hash := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= 16777619
+	}
+	return int(hash % uint32(n))
+}
+
+// clientShard returns the shard handling a client's lifecycle and inbound
+// messages.
+func (h *Hub) clientShard(clientID string) *shard {
+	return h.shards[shardIndex(clientID, len(h.shards))]
+}
+
+// channelShard returns the shard fanning out a channel's broadcasts.
+func (h *Hub) channelShard(channel string) *shard {
+	return h.shards[shardIndex(channel, len(h.shards))]
+}
+
+// runInbound processes a shard's client lifecycle events and inbound
+// messages until the hub stops. It blocks only while the handler queue
+// is full.
+func (h *Hub) runInbound(s *shard) {
+	for {
+		select {
+		case client := <-s.register:
+			h.addClient(client)
+		case client := <-s.unregister:
+			h.removeClient(client)
+		case msg := <-s.incoming:
+			h.handleMessage(msg)
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// runFanout delivers a shard's broadcasts until the hub stops.
+func (h *Hub) runFanout(s *shard) {
+	for {
+		select {
+		case bm := <-s.broadcast:
+			h.publishToBridge(bm.msg)
+			h.record(bm.channel, bm.msg)
+			h.broadcastToChannel(bm.channel, bm.msg)
+		case bm := <-s.localCast:
+			h.record(bm.channel, bm.msg)
+			h.broadcastToChannel(bm.channel, bm.msg)
+		case <-h.done:
+			return
+		}
+	}
+}
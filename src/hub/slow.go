@@ -0,0 +1,187 @@
+package hub
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// OverflowPolicy selects what happens when a client's send buffer is full.
+type OverflowPolicy string
+
+const (
+	OverflowDropNewest OverflowPolicy = "drop_newest" // discard the message being sent
+	OverflowDropOldest OverflowPolicy = "drop_oldest" // discard the oldest queued message
+	OverflowDisconnect OverflowPolicy = "disconnect"  // close the client with CloseCode
+	OverflowBlock      OverflowPolicy = "block"       // wait up to Timeout, then drop
+)
+
+// CloseSlowConsumer is the default close code sent when a slow client is
+// disconnected (RFC 6455 "policy violation").
+const CloseSlowConsumer = 1008
+
+// SlowConsumerPolicy configures delivery to clients that cannot keep up.
+type SlowConsumerPolicy struct {
+	Overflow  OverflowPolicy
+	Timeout   time.Duration // wait time for OverflowBlock
+	CloseCode int           // close code for OverflowDisconnect; 0 means CloseSlowConsumer
+}
+
+// Delivery errors returned by Send.
+var (
+	ErrClientNotFound = errors.New("client not connected to any instance")
+	ErrBufferFull     = errors.New("send buffer full")
+	ErrClientEvicted  = errors.New("client disconnected as slow consumer")
+)
+
+// SetSlowConsumerPolicy sets the hub-wide slow-consumer policy.
+func (h *Hub) SetSlowConsumerPolicy(p SlowConsumerPolicy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.slow = p
+}
+
+// SetChannelSlowConsumerPolicy overrides the slow-consumer policy for
+// broadcasts on one channel.
+func (h *Hub) SetChannelSlowConsumerPolicy(channel string, p SlowConsumerPolicy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.slowByChannel[channel] = p
+}
+
+// slowPolicy returns the policy for channel, falling back to the hub default.
+func (h *Hub) slowPolicy(channel string) SlowConsumerPolicy {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if p, ok := h.slowByChannel[channel]; ok {
+		return p
+	}
+	return h.slow
+}
+
+// Delivery reports where a direct message was routed.
+type Delivery int
+
+const (
+	DeliveredLocal  Delivery = iota + 1 // queued for a client on this instance
+	DeliveredRemote                     // accepted by another instance via the bridge
+)
+
+// Send delivers a message directly to a client, reporting why delivery
+// failed. See Route.
+func (h *Hub) Send(clientID string, msg types.Message) error {
+	_, err := h.Route(clientID, msg)
+	return err
+}
+
+// Route delivers a message directly to a client and reports where it went.
+// Local clients use the hub-wide slow-consumer policy; messages for a
+// client inside its resume grace period are buffered for replay; other
+// clients are tried through the bridge. ErrClientNotFound means no
+// instance knows the client.
+func (h *Hub) Route(clientID string, msg types.Message) (Delivery, error) {
+	h.mu.RLock()
+	client, ok := h.clients[clientID]
+	policy := h.slow
+	h.mu.RUnlock()
+	if ok {
+		return DeliveredLocal, h.deliver(client, msg, policy)
+	}
+	if h.bufferDirect(clientID, msg) {
+		return DeliveredLocal, nil
+	}
+
+	if db := h.directBridge(); db != nil && h.bridgeAvailable() {
+		delivered, err := db.SendDirect(clientID, msg)
+		if err != nil {
+			return 0, fmt.Errorf("route to %s: %w", clientID, err)
+		}
+		if delivered {
+			return DeliveredRemote, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrClientNotFound, clientID)
+}
+
+// SendToLocal delivers a message to a client on this instance only.
+// It implements bridge.DirectTarget.
+func (h *Hub) SendToLocal(clientID string, msg types.Message) bool {
+	h.mu.RLock()
+	client, ok := h.clients[clientID]
+	policy := h.slow
+	h.mu.RUnlock()
+	if !ok {
+		return h.bufferDirect(clientID, msg)
+	}
+	return h.deliver(client, msg, policy) == nil
+}
+
+// deliver queues msg on the client per policy. A client evicted by
+// OverflowDisconnect is removed from the hub before deliver returns.
+func (h *Hub) deliver(c *Client, msg types.Message, p SlowConsumerPolicy) error {
+	return h.deliverFrame(c, msg, nil, p)
+}
+
+// deliverFrame is deliver for a broadcast already encoded into frame,
+// which may be nil.
+func (h *Hub) deliverFrame(c *Client, msg types.Message, frame *types.Frame, p SlowConsumerPolicy) error {
+	err := c.enqueue(msg.WithFrame(frame), p)
+	if errors.Is(err, ErrClientEvicted) {
+		h.logger.Warn().Str("client_id", c.ID).Msg("disconnecting slow consumer")
+		h.removeClient(c)
+	} else if err != nil {
+		h.logger.Warn().Str("client_id", c.ID).Msg("send buffer full, dropping")
+	}
+	return err
+}
+
+// enqueue applies the overflow policy to a single send. It holds sendMu
+// for reading so Close cannot close Send mid-delivery.
+func (c *Client) enqueue(msg types.Message, p SlowConsumerPolicy) error {
+	c.sendMu.RLock()
+	defer c.sendMu.RUnlock()
+	if c.closed {
+		return fmt.Errorf("%w: %s", ErrClientNotFound, c.ID)
+	}
+
+	select {
+	case c.Send <- msg:
+		return nil
+	default:
+	}
+
+	switch p.Overflow {
+	case OverflowDropOldest:
+		select {
+		case <-c.Send:
+			c.dropped.Add(1)
+		default:
+		}
+		select {
+		case c.Send <- msg:
+			return nil
+		default:
+		}
+	case OverflowBlock:
+		timer := time.NewTimer(p.Timeout)
+		defer timer.Stop()
+		select {
+		case c.Send <- msg:
+			return nil
+		case <-timer.C:
+		}
+	case OverflowDisconnect:
+		c.dropped.Add(1)
+		code := p.CloseCode
+		if code == 0 {
+			code = CloseSlowConsumer
+		}
+		c.markEvicted(code)
+		return ErrClientEvicted
+	}
+
+	c.dropped.Add(1)
+	return ErrBufferFull
+}
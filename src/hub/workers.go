@@ -0,0 +1,169 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// Defaults for the handler worker pool.
+const (
+	DefaultHandlerWorkers = 32
+	DefaultHandlerQueue   = 1024
+)
+
+// handlerJob is an inbound message waiting for its channel's handler.
+type handlerJob struct {
+	client  *Client
+	msg     types.Message
+	handler handler
+}
+
+// workerPool runs handlers on a fixed set of goroutines. Jobs of one
+// client run one at a time in arrival order; jobs of different clients
+// run concurrently. At most queueSize jobs wait, after which dispatch
+// blocks the client's inbound shard.
+type workerPool struct {
+	workers int
+	timeout time.Duration
+	slots   chan struct{} // one per waiting job
+	work    chan handlerJob
+
+	mu      sync.Mutex
+	pending map[string][]handlerJob // clientID with a job in flight -> jobs behind it
+
+	queued   atomic.Int64
+	active   atomic.Int64
+	handled  atomic.Uint64
+	failed   atomic.Uint64
+	timedOut atomic.Uint64
+}
+
+func newWorkerPool(workers, queueSize int, timeout time.Duration) *workerPool {
+	return &workerPool{
+		workers: workers,
+		timeout: timeout,
+		slots:   make(chan struct{}, queueSize),
+		work:    make(chan handlerJob, queueSize),
+		pending: make(map[string][]handlerJob),
+	}
+}
+
+// dispatch queues a job. If the client already has a job in flight the
+// job waits behind it, otherwise any idle worker may take it.
+func (h *Hub) dispatch(job handlerJob) {
+	p := h.pool
+	select {
+	case p.slots <- struct{}{}:
+	case <-h.done:
+		return
+	}
+	p.queued.Add(1)
+
+	id := job.msg.ClientID
+	p.mu.Lock()
+	if q, busy := p.pending[id]; busy {
+		p.pending[id] = append(q, job)
+		p.mu.Unlock()
+		return
+	}
+	p.pending[id] = nil
+	p.mu.Unlock()
+	p.work <- job // cannot block: work holds as many jobs as there are slots
+}
+
+// runWorker takes jobs until the hub stops.
+func (h *Hub) runWorker() {
+	p := h.pool
+	for {
+		select {
+		case job := <-p.work:
+			h.runClientJobs(job)
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// runClientJobs runs job and then every job its client queued behind it.
+func (h *Hub) runClientJobs(job handlerJob) {
+	p := h.pool
+	id := job.msg.ClientID
+	for {
+		<-p.slots
+		p.queued.Add(-1)
+		h.invoke(job)
+
+		p.mu.Lock()
+		q := p.pending[id]
+		if len(q) == 0 {
+			delete(p.pending, id)
+			p.mu.Unlock()
+			return
+		}
+		job, p.pending[id] = q[0], q[1:]
+		p.mu.Unlock()
+	}
+}
+
+// invoke runs one handler under the pool timeout. The context is also
+// cancelled when the client leaves or the hub stops. Request handlers
+// have their errors and timeouts sent back to the client.
+func (h *Hub) invoke(job handlerJob) {
+	p := h.pool
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if p.timeout > 0 {
+		ctx, cancel = context.WithTimeout(job.client.ctx, p.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(job.client.ctx)
+	}
+	defer cancel()
+
+	req := &Request{
+		ClientID: job.msg.ClientID,
+		Identity: job.client.Identity(),
+		Message:  job.msg,
+		hub:      h,
+	}
+	p.active.Add(1)
+	err := job.handler.fn(ctx, req)
+	p.active.Add(-1)
+	p.handled.Add(1)
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		p.timedOut.Add(1)
+		h.logger.Warn().
+			Str("channel", job.msg.Channel).
+			Str("client_id", job.msg.ClientID).
+			Dur("timeout", p.timeout).
+			Msg("handler timed out")
+		if job.handler.replies {
+			req.fail(&HandlerError{Code: CodeTimeout, Message: "handler timed out"})
+		}
+	case err != nil:
+		p.failed.Add(1)
+		h.logger.Error().Err(err).Str("channel", job.msg.Channel).Msg("handler error")
+		if job.handler.replies && ctx.Err() == nil {
+			req.fail(err)
+		}
+	}
+}
+
+// stats snapshots the pool's counters.
+func (p *workerPool) stats() types.HandlerStats {
+	return types.HandlerStats{
+		Workers:   p.workers,
+		QueueSize: cap(p.slots),
+		Queued:    int(p.queued.Load()),
+		Active:    int(p.active.Load()),
+		Handled:   p.handled.Load(),
+		Failed:    p.failed.Load(),
+		TimedOut:  p.timedOut.Load(),
+	}
+}
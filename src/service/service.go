@@ -1,9 +1,11 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/orchestra-mcp/socket/src/auth"
 	"github.com/orchestra-mcp/socket/src/hub"
 	"github.com/orchestra-mcp/socket/src/types"
 	"github.com/rs/zerolog"
@@ -29,6 +31,43 @@ func (s *Service) RegisterHandler(channel string, handler types.MessageHandler)
 	s.logger.Debug().Str("channel", channel).Msg("handler registered")
 }
 
+// RegisterContextHandler registers a handler for a channel that receives
+// a context bounded by the handler timeout.
+func (s *Service) RegisterContextHandler(channel string, handler types.ContextHandler) {
+	s.hub.RegisterContextHandler(channel, handler)
+	s.logger.Debug().Str("channel", channel).Msg("handler registered")
+}
+
+// RegisterRequestHandler registers a handler for a channel that can reply
+// to the sending client; returned errors are sent back as error frames.
+func (s *Service) RegisterRequestHandler(channel string, handler hub.RequestHandler) {
+	s.hub.RegisterRequestHandler(channel, handler)
+	s.logger.Debug().Str("channel", channel).Msg("handler registered")
+}
+
+// RegisterMethod registers an RPC method clients can call.
+func (s *Service) RegisterMethod(name string, fn hub.Method) {
+	s.hub.RegisterMethod(name, fn)
+	s.logger.Debug().Str("method", name).Msg("rpc method registered")
+}
+
+// Call invokes a method on a client connected to this instance and waits
+// for its result.
+func (s *Service) Call(ctx context.Context, clientID, method string, params any) (any, error) {
+	return s.hub.Call(ctx, clientID, method, params)
+}
+
+// SetSubscribeAuthorizer installs the hook that approves client-initiated
+// subscribe frames on the system channel.
+func (s *Service) SetSubscribeAuthorizer(fn types.SubscribeAuthorizer) {
+	s.hub.SetSubscribeAuthorizer(fn)
+}
+
+// SetChannelAuthorizer installs the per-channel authorization policy.
+func (s *Service) SetChannelAuthorizer(a auth.ChannelAuthorizer) {
+	s.hub.SetChannelAuthorizer(a)
+}
+
 // Publish sends a message to all subscribers of a channel.
 func (s *Service) Publish(channel string, data any) error {
 	dataMap, ok := data.(map[string]any)
@@ -47,8 +86,8 @@ func (s *Service) Publish(channel string, data any) error {
 
 // Subscribe adds a client to a channel.
 func (s *Service) Subscribe(channel, clientID string) error {
-	if ok := s.hub.Subscribe(channel, clientID); !ok {
-		return fmt.Errorf("client %s not found", clientID)
+	if err := s.hub.SubscribeClient(channel, clientID); err != nil {
+		return err
 	}
 	s.logger.Debug().
 		Str("client_id", clientID).
@@ -60,7 +99,7 @@ func (s *Service) Subscribe(channel, clientID string) error {
 // Unsubscribe removes a client from a channel.
 func (s *Service) Unsubscribe(channel, clientID string) error {
 	if ok := s.hub.Unsubscribe(channel, clientID); !ok {
-		return fmt.Errorf("channel %s or client %s not found", channel, clientID)
+		return fmt.Errorf("client %s is not subscribed to %s", clientID, channel)
 	}
 	s.logger.Debug().
 		Str("client_id", clientID).
@@ -75,7 +114,7 @@ func (s *Service) OnConnection(cb func(clientID string)) {
 }
 
 // OnDisconnection registers a callback for disconnections.
-func (s *Service) OnDisconnection(cb func(clientID string)) {
+func (s *Service) OnDisconnection(cb func(clientID string, reason types.DisconnectReason)) {
 	s.hub.OnDisconnection(cb)
 }
 
@@ -84,7 +123,9 @@ func (s *Service) GetConnectedClients() []string {
 	return s.hub.ConnectedClients()
 }
 
-// SendToClient sends a message directly to a specific client.
+// SendToClient sends a message directly to a specific client, on this or
+// another instance. The error wraps hub.ErrClientNotFound when no instance
+// knows the client.
 func (s *Service) SendToClient(clientID, channel string, data any) error {
 	dataMap, ok := data.(map[string]any)
 	if !ok {
@@ -96,8 +137,12 @@ func (s *Service) SendToClient(clientID, channel string, data any) error {
 		Data:      dataMap,
 		Timestamp: time.Now(),
 	}
-	if ok := s.hub.SendToClient(clientID, msg); !ok {
-		return fmt.Errorf("client %s not found or buffer full", clientID)
+	where, err := s.hub.Route(clientID, msg)
+	if err != nil {
+		return err
+	}
+	if where == hub.DeliveredRemote {
+		s.logger.Debug().Str("client_id", clientID).Msg("direct message delivered remotely")
 	}
 	return nil
 }
@@ -107,6 +152,38 @@ func (s *Service) GetChannels() map[string]int {
 	return s.hub.Channels()
 }
 
+// GetClusterStats returns client and subscriber counts summed across
+// every instance, with a per-node breakdown.
+func (s *Service) GetClusterStats() (types.ClusterStats, error) {
+	return s.hub.ClusterStats()
+}
+
+// LocateClient reports which instance holds a client. The error wraps
+// hub.ErrClientNotFound when no live instance lists it.
+func (s *Service) LocateClient(clientID string) (types.ClientLocation, error) {
+	return s.hub.LocateClient(clientID)
+}
+
+// KickClient disconnects a client on whichever instance holds it. The
+// error wraps hub.ErrClientNotFound when no instance held it.
+func (s *Service) KickClient(clientID string) error {
+	where, err := s.hub.Kick(clientID)
+	if err != nil {
+		return err
+	}
+	s.logger.Info().
+		Str("client_id", clientID).
+		Bool("remote", where == hub.DeliveredRemote).
+		Msg("client kicked")
+	return nil
+}
+
+// OnNodeDown registers a callback for cluster instances that stopped
+// without shutting down, with the clients they held.
+func (s *Service) OnNodeDown(cb func(nodeID string, clients []string)) {
+	s.hub.OnNodeDown(cb)
+}
+
 // GetClientInfo returns info for a connected client, or error.
 func (s *Service) GetClientInfo(clientID string) (*types.ClientInfo, error) {
 	info := s.hub.ClientInfo(clientID)
@@ -115,3 +192,22 @@ func (s *Service) GetClientInfo(clientID string) (*types.ClientInfo, error) {
 	}
 	return info, nil
 }
+
+// GetPresence returns the members of a presence channel.
+func (s *Service) GetPresence(channel string) ([]types.Member, error) {
+	if !auth.IsPresence(channel) {
+		return nil, fmt.Errorf("channel %s is not a presence channel", channel)
+	}
+	return s.hub.Presence(channel), nil
+}
+
+// SetChannelHistory retains messages on channels matching pattern. The
+// policy must set a Limit or MaxAge.
+func (s *Service) SetChannelHistory(pattern string, p hub.HistoryPolicy) error {
+	return s.hub.SetChannelHistory(pattern, p)
+}
+
+// History returns retained messages for a channel, oldest first.
+func (s *Service) History(channel string, opts hub.HistoryOptions) []types.Message {
+	return s.hub.History(channel, opts)
+}
@@ -1,25 +1,150 @@
 package types
 
-import "time"
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
 
 // Message is a WebSocket message.
 type Message struct {
+	ID        string         `json:"id,omitempty"` // set by clients on requests; replies and error frames echo it
 	Channel   string         `json:"channel"`
 	Event     string         `json:"event"`
 	Data      map[string]any `json:"data,omitempty"`
 	ClientID  string         `json:"client_id,omitempty"`
 	Timestamp time.Time      `json:"timestamp"`
+
+	frame *Frame // shared encoding attached by the hub, see WithFrame
+}
+
+// WithFrame returns a copy of m carrying f, m already encoded. The hub
+// attaches frames to broadcasts so that connections implementing
+// FrameConn write the shared bytes instead of marshalling m again.
+func (m Message) WithFrame(f *Frame) Message {
+	m.frame = f
+	return m
+}
+
+// Frame returns the encoding attached by WithFrame, or nil.
+func (m Message) Frame() *Frame {
+	return m.frame
 }
 
 // MessageHandler handles incoming messages on a channel.
 type MessageHandler func(clientID string, msg Message) error
 
+// ContextHandler is a MessageHandler that receives a context. The context
+// expires after the hub's handler timeout and is cancelled when the client
+// disconnects or the hub stops; handlers doing I/O should pass it on.
+type ContextHandler func(ctx context.Context, clientID string, msg Message) error
+
+// SystemChannel is the reserved channel for built-in control frames.
+// Messages sent by clients on this channel are handled by the hub itself
+// and never reach handlers registered with RegisterHandler.
+const SystemChannel = "$system"
+
+// Control events exchanged on SystemChannel.
+const (
+	EventSubscribe    = "subscribe"
+	EventUnsubscribe  = "unsubscribe"
+	EventSubscribed   = "subscribed"
+	EventUnsubscribed = "unsubscribed"
+	EventError        = "error"
+	EventSession      = "session"    // resume token issued on connect
+	EventReply        = "reply"      // handler reply, sent on the request's channel
+	EventRPC          = "rpc"        // call a method; data: method, params, timeout_ms
+	EventRPCResult    = "rpc_result" // answer to an rpc frame with the same id
+)
+
+// Presence events delivered on presence- channels.
+const (
+	EventMembers       = "members"        // full member list, sent to a new subscriber
+	EventMemberAdded   = "member_added"   // a subscriber joined
+	EventMemberRemoved = "member_removed" // a subscriber left or disconnected
+)
+
+// Member describes a subscriber of a presence channel.
+type Member struct {
+	ClientID string         `json:"client_id"`
+	UserID   string         `json:"user_id,omitempty"`
+	Info     map[string]any `json:"info,omitempty"`
+}
+
+// SubscribeAuthorizer decides whether a client may join a channel through
+// a subscribe control frame. Returning a non-nil error rejects the request
+// and the error text is sent back to the client.
+type SubscribeAuthorizer func(clientID, channel string) error
+
+// Identity describes the authenticated principal behind a connection.
+type Identity struct {
+	UserID string         `json:"user_id"`
+	Claims map[string]any `json:"claims,omitempty"`
+}
+
 // ClientInfo holds metadata about a connected WebSocket client.
 type ClientInfo struct {
-	ID          string    `json:"id"`
-	ConnectedAt time.Time `json:"connected_at"`
-	Channels    []string  `json:"channels"`
-	UserAgent   string    `json:"user_agent,omitempty"`
+	ID          string         `json:"id"`
+	ConnectedAt time.Time      `json:"connected_at"`
+	Channels    []string       `json:"channels"`
+	UserAgent   string         `json:"user_agent,omitempty"`
+	UserID      string         `json:"user_id,omitempty"`
+	Claims      map[string]any `json:"claims,omitempty"`
+	Dropped     uint64         `json:"dropped"` // messages discarded by the slow-consumer policy
+}
+
+// Stats summarizes hub activity.
+type Stats struct {
+	Clients  int               `json:"clients"`
+	Channels int               `json:"channels"`
+	Rejected map[string]uint64 `json:"rejected"` // connection rejections by limit scope
+	Handlers HandlerStats      `json:"handlers"`
+}
+
+// HandlerStats reports the handler worker pool.
+type HandlerStats struct {
+	Workers   int    `json:"workers"`
+	QueueSize int    `json:"queue_size"`
+	Queued    int    `json:"queued"` // messages waiting for a worker
+	Active    int    `json:"active"` // handlers running now
+	Handled   uint64 `json:"handled"`
+	Failed    uint64 `json:"failed"`    // handlers that returned an error
+	TimedOut  uint64 `json:"timed_out"` // handlers still running at their deadline
+}
+
+// NodeStats is one instance's registry entry and counts as published to
+// the cluster.
+type NodeStats struct {
+	InstanceID string         `json:"instance_id"`
+	Address    string         `json:"address,omitempty"` // advertised host or host:port
+	StartedAt  time.Time      `json:"started_at"`
+	Clients    int            `json:"clients"`
+	Channels   map[string]int `json:"channels"` // subscribers per channel
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// ClientLocation reports which instance holds a client.
+type ClientLocation struct {
+	ClientID   string `json:"client_id"`
+	InstanceID string `json:"instance_id"`
+	Local      bool   `json:"local"` // held by the instance answering
+}
+
+// NodeDown describes an instance that stopped without shutting down
+// cleanly: the clients its directory listed and the presence members it
+// held, by channel.
+type NodeDown struct {
+	NodeID  string              `json:"node_id"`
+	Clients []string            `json:"clients"`
+	Members map[string][]Member `json:"members,omitempty"`
+}
+
+// ClusterStats aggregates the counts of every live instance.
+type ClusterStats struct {
+	Nodes    []NodeStats    `json:"nodes"`
+	Clients  int            `json:"clients"`
+	Channels map[string]int `json:"channels"` // subscribers per channel across nodes
 }
 
 // Conn abstracts a WebSocket connection for testability.
@@ -28,3 +153,66 @@ type Conn interface {
 	ReadJSON(v any) error
 	Close() error
 }
+
+// HeartbeatConn is implemented by connections that support keep-alive
+// pings and I/O deadlines. Clients whose Conn does not implement it run
+// without heartbeats.
+type HeartbeatConn interface {
+	Conn
+	WritePing(deadline time.Time) error
+	SetPongHandler(h func() error)
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+}
+
+// Frame is a message encoded once and shared by every client a broadcast
+// is delivered to. Frames must not be copied.
+type Frame struct {
+	Data []byte // JSON encoding of the message
+
+	once     sync.Once
+	prepared any
+	err      error
+}
+
+// NewFrame encodes msg as JSON.
+func NewFrame(msg Message) (*Frame, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &Frame{Data: data}, nil
+}
+
+// Prepared returns a connection-specific form of the frame, such as the
+// websocket library's prepared message. build runs on first use only;
+// later callers share its result.
+func (f *Frame) Prepared(build func(data []byte) (any, error)) (any, error) {
+	f.once.Do(func() { f.prepared, f.err = build(f.Data) })
+	return f.prepared, f.err
+}
+
+// FrameConn is implemented by connections that can write a pre-encoded
+// Frame. Clients whose Conn does not implement it marshal every message
+// with WriteJSON.
+type FrameConn interface {
+	Conn
+	WriteFrame(f *Frame) error
+}
+
+// DisconnectReason explains why a client left the hub.
+type DisconnectReason string
+
+const (
+	DisconnectClosed       DisconnectReason = "closed"        // peer closed the connection or a read failed
+	DisconnectTimeout      DisconnectReason = "timeout"       // no pong or frame before the read deadline
+	DisconnectWriteError   DisconnectReason = "write_error"   // a write or ping failed or timed out
+	DisconnectServer       DisconnectReason = "server"        // removed by the server via Unregister
+	DisconnectSlowConsumer DisconnectReason = "slow_consumer" // evicted because its send buffer was full
+)
+
+// CloseCoder is implemented by connections that can send a close frame
+// with a status code before closing.
+type CloseCoder interface {
+	CloseWithCode(code int, reason string) error
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/orchestra-mcp/socket/src/auth"
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// fakeRequest implements auth.Request from plain maps.
+type fakeRequest struct {
+	headers map[string]string
+	cookies map[string]string
+	query   map[string]string
+}
+
+func (r *fakeRequest) Header(key string) string  { return r.headers[key] }
+func (r *fakeRequest) Cookie(name string) string { return r.cookies[name] }
+func (r *fakeRequest) Query(key string) string   { return r.query[key] }
+func (r *fakeRequest) RemoteAddr() string        { return "127.0.0.1" }
+
+func newTokenAuth() *auth.TokenAuthenticator {
+	return &auth.TokenAuthenticator{
+		CookieName: "session",
+		QueryParam: "token",
+		Validate: func(token string) (*types.Identity, error) {
+			switch token {
+			case "good":
+				return &types.Identity{UserID: "u1", Claims: map[string]any{"role": "admin"}}, nil
+			case "banned":
+				return nil, auth.ErrForbidden
+			}
+			return nil, auth.ErrUnauthorized
+		},
+	}
+}
+
+func TestTokenAuthenticatorSources(t *testing.T) {
+	a := newTokenAuth()
+	reqs := map[string]*fakeRequest{
+		"header": {headers: map[string]string{"Authorization": "Bearer good"}},
+		"cookie": {cookies: map[string]string{"session": "good"}},
+		"query":  {query: map[string]string{"token": "good"}},
+	}
+	for name, req := range reqs {
+		id, err := a.Authenticate(req)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if id.UserID != "u1" {
+			t.Errorf("%s: expected u1, got %s", name, id.UserID)
+		}
+	}
+}
+
+func TestTokenAuthenticatorRejects(t *testing.T) {
+	a := newTokenAuth()
+
+	if _, err := a.Authenticate(&fakeRequest{}); !errors.Is(err, auth.ErrUnauthorized) {
+		t.Errorf("missing token should be unauthorized, got %v", err)
+	}
+	req := &fakeRequest{headers: map[string]string{"Authorization": "Bearer banned"}}
+	if _, err := a.Authenticate(req); !errors.Is(err, auth.ErrForbidden) {
+		t.Errorf("banned token should be forbidden, got %v", err)
+	}
+}
+
+func TestClientInfoIncludesIdentity(t *testing.T) {
+	h := newTestHub(t)
+	client, _ := registerClient(t, h, "id-client")
+	client.SetIdentity(types.Identity{UserID: "u42", Claims: map[string]any{"tenant": "acme"}})
+
+	info := h.ClientInfo("id-client")
+	if info == nil {
+		t.Fatal("expected client info")
+	}
+	if info.UserID != "u42" || info.Claims["tenant"] != "acme" {
+		t.Errorf("unexpected identity in info: %+v", info)
+	}
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// fakeChannelBridge records which channels the hub asks it to watch.
+type fakeChannelBridge struct {
+	mu      sync.Mutex
+	watched map[string]bool
+	calls   int
+}
+
+func newFakeChannelBridge() *fakeChannelBridge {
+	return &fakeChannelBridge{watched: map[string]bool{}}
+}
+
+func (b *fakeChannelBridge) Publish(types.Message) error { return nil }
+func (b *fakeChannelBridge) Available() bool             { return true }
+
+func (b *fakeChannelBridge) Watch(channel string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.watched[channel] = true
+	b.calls++
+	return nil
+}
+
+func (b *fakeChannelBridge) Unwatch(channel string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.watched, channel)
+	b.calls++
+	return nil
+}
+
+func (b *fakeChannelBridge) isWatching(channel string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.watched[channel]
+}
+
+func (b *fakeChannelBridge) callCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.calls
+}
+
+func TestBridgeWatchesChannelsWithLocalInterest(t *testing.T) {
+	h := newTestHub(t)
+	b := newFakeChannelBridge()
+	h.SetBridge(b)
+
+	_, _ = registerClient(t, h, "a")
+	_, _ = registerClient(t, h, "b")
+
+	h.Subscribe("news", "a")
+	if !b.isWatching("news") {
+		t.Fatal("first subscriber should start watching the channel")
+	}
+	h.Subscribe("news", "b")
+	if b.callCount() != 1 {
+		t.Errorf("second subscriber should not watch again, got %d calls", b.callCount())
+	}
+
+	h.Unsubscribe("news", "a")
+	if !b.isWatching("news") {
+		t.Error("channel should stay watched while a subscriber remains")
+	}
+	h.Unsubscribe("news", "b")
+	if b.isWatching("news") {
+		t.Error("last unsubscribe should stop watching the channel")
+	}
+}
+
+func TestBridgeUnwatchesOnDisconnect(t *testing.T) {
+	h := newTestHub(t)
+	b := newFakeChannelBridge()
+	h.SetBridge(b)
+
+	client, _ := registerClient(t, h, "leaver")
+	h.Subscribe("news", "leaver")
+
+	h.Unregister(client)
+	time.Sleep(20 * time.Millisecond)
+	if b.isWatching("news") {
+		t.Error("channel should be unwatched once its only subscriber leaves")
+	}
+}
+
+func TestBridgeKeepsWatchingForDetachedSessions(t *testing.T) {
+	h := newTestHub(t)
+	h.SetResume(50*time.Millisecond, 10)
+	b := newFakeChannelBridge()
+	h.SetBridge(b)
+
+	_, conn := connectClient(t, h, "away")
+	h.Subscribe("news", "away")
+
+	conn.Close()
+	time.Sleep(20 * time.Millisecond)
+	if !b.isWatching("news") {
+		t.Error("a detached session should keep the channel watched")
+	}
+
+	time.Sleep(80 * time.Millisecond)
+	if b.isWatching("news") {
+		t.Error("channel should be unwatched once the session expires")
+	}
+}
+
+func TestSetBridgeWatchesExistingChannels(t *testing.T) {
+	h := newTestHub(t)
+	_, _ = registerClient(t, h, "early")
+	h.Subscribe("news", "early")
+
+	b := newFakeChannelBridge()
+	h.SetBridge(b)
+	if !b.isWatching("news") {
+		t.Error("SetBridge should watch channels that already have subscribers")
+	}
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/orchestra-mcp/socket/src/service"
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/rs/zerolog"
+)
+
+// fakeClusterBridge reports a fixed set of node stats.
+type fakeClusterBridge struct {
+	nodes []types.NodeStats
+	err   error
+}
+
+func (b *fakeClusterBridge) Publish(types.Message) error { return nil }
+func (b *fakeClusterBridge) Available() bool             { return true }
+
+func (b *fakeClusterBridge) NodeStats() ([]types.NodeStats, error) {
+	return b.nodes, b.err
+}
+
+func TestClusterStatsAggregatesNodes(t *testing.T) {
+	h := newTestHub(t)
+	h.SetBridge(&fakeClusterBridge{nodes: []types.NodeStats{
+		{InstanceID: "node-b", Clients: 1, Channels: map[string]int{"news": 1, "chat": 1}},
+		{InstanceID: "node-a", Clients: 3, Channels: map[string]int{"news": 2}},
+	}})
+
+	svc := service.New(h, zerolog.Nop())
+	stats, err := svc.GetClusterStats()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Clients != 4 || stats.Channels["news"] != 3 || stats.Channels["chat"] != 1 {
+		t.Errorf("bad aggregate: %+v", stats)
+	}
+	if len(stats.Nodes) != 2 || stats.Nodes[0].InstanceID != "node-a" {
+		t.Errorf("nodes should be sorted by instance ID: %+v", stats.Nodes)
+	}
+}
+
+func TestClusterStatsStandalone(t *testing.T) {
+	h := newTestHub(t)
+	_, _ = registerClient(t, h, "solo")
+	h.Subscribe("news", "solo")
+
+	stats, err := h.ClusterStats()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stats.Nodes) != 1 || stats.Clients != 1 || stats.Channels["news"] != 1 {
+		t.Errorf("standalone cluster should be the local node: %+v", stats)
+	}
+}
+
+func TestClusterStatsBridgeError(t *testing.T) {
+	h := newTestHub(t)
+	down := errors.New("redis down")
+	h.SetBridge(&fakeClusterBridge{err: down})
+
+	if _, err := h.ClusterStats(); !errors.Is(err, down) {
+		t.Errorf("expected bridge error, got %v", err)
+	}
+}
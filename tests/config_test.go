@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/orchestra-mcp/socket/config"
+)
+
+func TestConfigFromMapOverlaysDefaults(t *testing.T) {
+	cfg, err := config.FromMap(map[string]any{
+		"max_connections":       50,
+		"ping_interval_seconds": float64(15),
+		"bridge":                map[string]any{"addr": "redis:6379"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MaxConnections != 50 || cfg.PingInterval != 15 {
+		t.Errorf("overrides not applied: %+v", cfg)
+	}
+	if cfg.ReadBufferSize != 1024 || cfg.SendBufferSize != 256 {
+		t.Errorf("defaults not kept: %+v", cfg)
+	}
+	if !cfg.Bridge.Enabled || cfg.Bridge.Addr != "redis:6379" || cfg.Bridge.Prefix != "orchestra:ws:" {
+		t.Errorf("bridge section not merged: %+v", cfg.Bridge)
+	}
+}
+
+func TestConfigFromMapRejectsUnknownKeys(t *testing.T) {
+	if _, err := config.FromMap(map[string]any{"read_buffer": 2048}); err == nil {
+		t.Error("expected error for unknown key")
+	}
+}
+
+func TestConfigFromMapValidates(t *testing.T) {
+	_, err := config.FromMap(map[string]any{
+		"read_buffer_size": 0,
+		"max_connections":  -1,
+	})
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{"read_buffer_size", "max_connections"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %s", err, want)
+		}
+	}
+}
+
+func TestConfigToMapRoundTrip(t *testing.T) {
+	cfg, err := config.FromMap(config.DefaultConfig().ToMap())
+	if err != nil {
+		t.Fatalf("defaults should round-trip: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, config.DefaultConfig()) {
+		t.Errorf("round-trip mismatch: %+v", cfg)
+	}
+}
+
+func TestConfigValidatesBridgeBackoff(t *testing.T) {
+	_, err := config.FromMap(map[string]any{
+		"bridge": map[string]any{"reconnect_min_ms": 1000, "reconnect_max_ms": 10},
+	})
+	if err == nil || !strings.Contains(err.Error(), "reconnect_max_ms") {
+		t.Errorf("expected backoff validation error, got %v", err)
+	}
+}
+
+func TestConfigSelectsBridgeDriver(t *testing.T) {
+	cfg, err := config.FromMap(map[string]any{
+		"bridge": map[string]any{
+			"driver": "nats",
+			"nats":   map[string]any{"url": "nats://nats:4222", "jetstream": true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Bridge.Driver != "nats" || cfg.Bridge.Nats.URL != "nats://nats:4222" || cfg.Bridge.Nats.Stream != "ORCHESTRA_WS" {
+		t.Errorf("nats section not merged: %+v", cfg.Bridge.Nats)
+	}
+
+	cfg, err = config.FromMap(map[string]any{"bridge": map[string]any{
+		"driver":   "postgres",
+		"postgres": map[string]any{"dsn": "postgres://localhost/app"},
+	}})
+	if err != nil || cfg.Bridge.Postgres.Channel != "orchestra_ws" {
+		t.Errorf("postgres driver not accepted: %v %+v", err, cfg)
+	}
+	if _, err := config.FromMap(map[string]any{"bridge": map[string]any{"driver": "postgres"}}); err == nil {
+		t.Error("expected error for missing postgres dsn")
+	}
+
+	if _, err := config.FromMap(map[string]any{"bridge": map[string]any{"driver": "kafka"}}); err == nil {
+		t.Error("expected error for unknown driver")
+	}
+	_, err = config.FromMap(map[string]any{"bridge": map[string]any{
+		"driver": "nats",
+		"nats":   map[string]any{"subject": "ws.*"},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "bridge.nats.subject") {
+		t.Errorf("expected subject validation error, got %v", err)
+	}
+}
+
+func TestConfigRedisTopology(t *testing.T) {
+	cfg, err := config.FromMap(map[string]any{"bridge": map[string]any{
+		"addrs":       []any{"sentinel-1:26379", "sentinel-2:26379"},
+		"master_name": "mymaster",
+		"username":    "ws",
+		"tls":         map[string]any{"enabled": true, "ca_file": "/etc/redis/ca.pem"},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Bridge.Addrs) != 2 || cfg.Bridge.MasterName != "mymaster" || !cfg.Bridge.TLS.Enabled {
+		t.Errorf("sentinel settings not merged: %+v", cfg.Bridge)
+	}
+
+	cases := map[string]map[string]any{
+		"mutually exclusive": {"cluster": true, "master_name": "mymaster", "addrs": []any{"a:1"}},
+		"db must be 0":       {"cluster": true, "db": 2},
+		"must list":          {"master_name": "mymaster"},
+		"set together":       {"tls": map[string]any{"enabled": true, "cert_file": "/etc/redis/client.pem"}},
+		"streams.max_len":    {"streams": map[string]any{"enabled": true, "max_len": -1}},
+	}
+	for want, bridge := range cases {
+		_, err := config.FromMap(map[string]any{"bridge": bridge})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: expected error containing %q, got %v", bridge, want, err)
+		}
+	}
+}
+
+func TestConfigRedisStreams(t *testing.T) {
+	cfg, err := config.FromMap(map[string]any{"bridge": map[string]any{
+		"streams": map[string]any{"enabled": true, "consumer": "web-1"},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := cfg.Bridge.Streams
+	if !s.Enabled || s.Consumer != "web-1" || s.MaxLen != 10000 || s.MaxAgeSeconds != 3600 {
+		t.Errorf("streams section not merged with defaults: %+v", s)
+	}
+}
+
+func TestConfigValidatesHandlerPool(t *testing.T) {
+	_, err := config.FromMap(map[string]any{
+		"handler_workers":         0,
+		"handler_timeout_seconds": -1,
+	})
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{"handler_workers", "handler_timeout_seconds"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %s", err, want)
+		}
+	}
+}
+
+func TestConfigRedisFromEnv(t *testing.T) {
+	t.Setenv("REDIS_ADDR", "redis-env:6379")
+	t.Setenv("REDIS_PASSWORD", "secret")
+	t.Setenv("REDIS_STREAMS", "true")
+
+	cfg, err := config.FromMap(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Bridge.Addr != "redis-env:6379" || cfg.Bridge.Password != "secret" || !cfg.Bridge.Streams.Enabled {
+		t.Errorf("environment not applied: %+v", cfg.Bridge)
+	}
+	if cfg.Bridge.Prefix != "orchestra:ws:" || cfg.Bridge.Streams.MaxLen != 10000 {
+		t.Errorf("defaults not kept: %+v", cfg.Bridge)
+	}
+
+	cfg, err = config.FromMap(map[string]any{"bridge": map[string]any{"addr": "redis-cfg:6379"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Bridge.Addr != "redis-cfg:6379" || cfg.Bridge.Password != "secret" {
+		t.Errorf("plugin config should override the environment only where set: %+v", cfg.Bridge)
+	}
+}
@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/auth"
+	"github.com/orchestra-mcp/socket/src/hub"
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// connectClient registers a mock client and starts both pumps so frames
+// pushed onto the mock connection flow through the hub.
+func connectClient(t *testing.T, h *hub.Hub, id string) (*hub.Client, *mockConn) {
+	t.Helper()
+	client, conn := registerClient(t, h, id)
+	go client.ReadPump()
+	return client, conn
+}
+
+// lastMessage returns the most recent message written to the connection.
+func lastMessage(t *testing.T, conn *mockConn) types.Message {
+	t.Helper()
+	written := conn.getWritten()
+	if len(written) == 0 {
+		t.Fatal("expected a written message")
+	}
+	msg, ok := written[len(written)-1].(types.Message)
+	if !ok {
+		t.Fatalf("unexpected frame type %T", written[len(written)-1])
+	}
+	return msg
+}
+
+func TestControlSubscribe(t *testing.T) {
+	h := newTestHub(t)
+	_, conn := connectClient(t, h, "ctl-1")
+
+	conn.readCh <- types.Message{
+		Channel: types.SystemChannel,
+		Event:   types.EventSubscribe,
+		Data:    map[string]any{"channel": "news"},
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if h.Channels()["news"] != 1 {
+		t.Fatalf("expected 1 subscriber on news, got %d", h.Channels()["news"])
+	}
+	reply := lastMessage(t, conn)
+	if reply.Event != types.EventSubscribed || reply.Data["channel"] != "news" {
+		t.Errorf("unexpected reply: %+v", reply)
+	}
+
+	conn.readCh <- types.Message{
+		Channel: types.SystemChannel,
+		Event:   types.EventUnsubscribe,
+		Data:    map[string]any{"channel": "news"},
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if _, ok := h.Channels()["news"]; ok {
+		t.Error("expected news channel to be removed")
+	}
+	if reply := lastMessage(t, conn); reply.Event != types.EventUnsubscribed {
+		t.Errorf("expected unsubscribed reply, got %s", reply.Event)
+	}
+}
+
+func TestControlSubscribeDenied(t *testing.T) {
+	h := newTestHub(t)
+	h.SetSubscribeAuthorizer(func(clientID, channel string) error {
+		if channel == "admin" {
+			return errors.New("forbidden")
+		}
+		return nil
+	})
+	_, conn := connectClient(t, h, "ctl-2")
+
+	conn.readCh <- types.Message{
+		Channel: types.SystemChannel,
+		Event:   types.EventSubscribe,
+		Data:    map[string]any{"channel": "admin"},
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if _, ok := h.Channels()["admin"]; ok {
+		t.Error("denied subscription should not create channel")
+	}
+	reply := lastMessage(t, conn)
+	if reply.Event != types.EventError || reply.Data["error"] != "forbidden" {
+		t.Errorf("unexpected reply: %+v", reply)
+	}
+}
+
+func TestControlRejectsReservedChannel(t *testing.T) {
+	h := newTestHub(t)
+	_, conn := connectClient(t, h, "ctl-3")
+
+	conn.readCh <- types.Message{
+		Channel: types.SystemChannel,
+		Event:   types.EventSubscribe,
+		Data:    map[string]any{"channel": types.SystemChannel},
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if reply := lastMessage(t, conn); reply.Event != types.EventError {
+		t.Errorf("expected error reply, got %s", reply.Event)
+	}
+}
+
+func TestControlUnsubscribeNotSubscribed(t *testing.T) {
+	h := newTestHub(t)
+	_, conn := connectClient(t, h, "ctl-4")
+	_, _ = registerClient(t, h, "other")
+	if err := h.SubscribeClient("news", "other"); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	conn.readCh <- types.Message{
+		ID:      "u1",
+		Channel: types.SystemChannel,
+		Event:   types.EventUnsubscribe,
+		Data:    map[string]any{"channel": "news"},
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	reply := lastMessage(t, conn)
+	if reply.Event != types.EventError || reply.ID != "u1" || reply.Data["code"] != hub.CodeNotSubscribed {
+		t.Errorf("expected a not_subscribed error, got %+v", reply)
+	}
+	if h.Channels()["news"] != 1 {
+		t.Error("the other subscriber should be unaffected")
+	}
+	if h.Unsubscribe("news", "ctl-4") {
+		t.Error("Unsubscribe should report a client that was not subscribed")
+	}
+}
+
+// countingAuthorizer allows everything and counts the checks.
+type countingAuthorizer struct{ calls atomic.Int32 }
+
+func (a *countingAuthorizer) Authorize(types.ClientInfo, string, auth.Action) error {
+	a.calls.Add(1)
+	return nil
+}
+
+func TestControlSubscribeAuthorizesOnce(t *testing.T) {
+	h := newTestHub(t)
+	authz := &countingAuthorizer{}
+	h.SetChannelAuthorizer(authz)
+	_, conn := connectClient(t, h, "ctl-5")
+
+	conn.readCh <- types.Message{
+		Channel: types.SystemChannel,
+		Event:   types.EventSubscribe,
+		Data:    map[string]any{"channel": "news"},
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if reply := lastMessage(t, conn); reply.Event != types.EventSubscribed {
+		t.Fatalf("expected subscribed reply, got %+v", reply)
+	}
+	if n := authz.calls.Load(); n != 1 {
+		t.Errorf("expected one authorization check, got %d", n)
+	}
+}
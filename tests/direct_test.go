@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/hub"
+	"github.com/orchestra-mcp/socket/src/service"
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/rs/zerolog"
+)
+
+// fakeDirectBridge pretends the clients in remote live on another instance.
+type fakeDirectBridge struct {
+	mu       sync.Mutex
+	remote   map[string]bool
+	attached map[string]bool
+	sent     []string
+}
+
+func newFakeDirectBridge(remote ...string) *fakeDirectBridge {
+	b := &fakeDirectBridge{remote: map[string]bool{}, attached: map[string]bool{}}
+	for _, id := range remote {
+		b.remote[id] = true
+	}
+	return b
+}
+
+func (b *fakeDirectBridge) Publish(types.Message) error { return nil }
+func (b *fakeDirectBridge) Available() bool             { return true }
+
+func (b *fakeDirectBridge) Attach(id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.attached[id] = true
+	return nil
+}
+
+func (b *fakeDirectBridge) Detach(id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.attached, id)
+	return nil
+}
+
+func (b *fakeDirectBridge) SendDirect(id string, _ types.Message) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sent = append(b.sent, id)
+	return b.remote[id], nil
+}
+
+func (b *fakeDirectBridge) isAttached(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.attached[id]
+}
+
+func TestRouteDirectMessages(t *testing.T) {
+	h := newTestHub(t)
+	b := newFakeDirectBridge("elsewhere")
+	h.SetBridge(b)
+	_, _ = registerClient(t, h, "here")
+
+	msg := types.Message{Channel: "dm", Event: "hi"}
+	if where, err := h.Route("here", msg); err != nil || where != hub.DeliveredLocal {
+		t.Errorf("local client: got %v, %v", where, err)
+	}
+	if where, err := h.Route("elsewhere", msg); err != nil || where != hub.DeliveredRemote {
+		t.Errorf("remote client: got %v, %v", where, err)
+	}
+	if _, err := h.Route("nowhere", msg); !errors.Is(err, hub.ErrClientNotFound) {
+		t.Errorf("unknown client: expected ErrClientNotFound, got %v", err)
+	}
+
+	svc := service.New(h, zerolog.Nop())
+	if err := svc.SendToClient("elsewhere", "dm", "hi"); err != nil {
+		t.Errorf("service should accept remote delivery: %v", err)
+	}
+	if err := svc.SendToClient("nowhere", "dm", "hi"); !errors.Is(err, hub.ErrClientNotFound) {
+		t.Errorf("service should report unknown client, got %v", err)
+	}
+}
+
+func TestBridgeAttachFollowsClientLifecycle(t *testing.T) {
+	h := newTestHub(t)
+	b := newFakeDirectBridge()
+
+	early, _ := registerClient(t, h, "early")
+	h.SetBridge(b)
+	if !b.isAttached("early") {
+		t.Error("clients registered before SetBridge should be attached")
+	}
+
+	_, _ = registerClient(t, h, "late")
+	if !b.isAttached("late") {
+		t.Error("new clients should be attached")
+	}
+
+	h.Unregister(early)
+	time.Sleep(20 * time.Millisecond)
+	if b.isAttached("early") {
+		t.Error("removed clients should be detached")
+	}
+}
@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/hub"
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+func TestKickLocalClient(t *testing.T) {
+	h := newTestHub(t)
+	h.SetResume(time.Second, 10)
+
+	var mu sync.Mutex
+	var reason types.DisconnectReason
+	h.OnDisconnection(func(_ string, r types.DisconnectReason) {
+		mu.Lock()
+		defer mu.Unlock()
+		reason = r
+	})
+	_, conn := registerClient(t, h, "target")
+	token, _ := sessionFrame(t, conn).Data["token"].(string)
+
+	loc, err := h.LocateClient("target")
+	if err != nil || !loc.Local || loc.InstanceID != "local" {
+		t.Fatalf("expected a local client, got %+v, %v", loc, err)
+	}
+
+	if where, err := h.Kick("target"); err != nil || where != hub.DeliveredLocal {
+		t.Fatalf("expected a local kick, got %v, %v", where, err)
+	}
+	if h.ClientInfo("target") != nil {
+		t.Error("kicked client should be removed")
+	}
+	mu.Lock()
+	if reason != types.DisconnectServer {
+		t.Errorf("expected reason %q, got %q", types.DisconnectServer, reason)
+	}
+	mu.Unlock()
+	if _, ok := h.ClaimSession(token, ""); ok {
+		t.Error("a kicked client must not be resumable")
+	}
+
+	if _, err := h.Kick("target"); !errors.Is(err, hub.ErrClientNotFound) {
+		t.Errorf("expected ErrClientNotFound, got %v", err)
+	}
+	if _, err := h.LocateClient("target"); !errors.Is(err, hub.ErrClientNotFound) {
+		t.Errorf("expected ErrClientNotFound, got %v", err)
+	}
+}
+
+func TestKickDetachedSession(t *testing.T) {
+	h := newTestHub(t)
+	h.SetResume(time.Second, 10)
+
+	_, conn := connectClient(t, h, "away")
+	token, _ := sessionFrame(t, conn).Data["token"].(string)
+	conn.Close()
+	time.Sleep(30 * time.Millisecond)
+
+	if !h.KickLocal("away") {
+		t.Fatal("a detached session should be kickable")
+	}
+	if _, ok := h.ClaimSession(token, ""); ok {
+		t.Error("a kicked session must not be resumable")
+	}
+	if h.KickLocal("away") {
+		t.Error("nothing left to kick")
+	}
+}
+
+func TestDetachedSessionStaysAttached(t *testing.T) {
+	h := newTestHub(t)
+	h.SetResume(time.Second, 10)
+	b := newFakeDirectBridge()
+	h.SetBridge(b)
+
+	_, conn := connectClient(t, h, "away")
+	conn.Close()
+	time.Sleep(30 * time.Millisecond)
+	if !b.isAttached("away") {
+		t.Fatal("a detached session should stay attached to the bridge")
+	}
+	if !h.KickLocal("away") {
+		t.Fatal("a detached session should be kickable")
+	}
+	if b.isAttached("away") {
+		t.Error("a kicked session should be detached from the bridge")
+	}
+
+	h.SetResume(20*time.Millisecond, 10)
+	_, conn = connectClient(t, h, "gone")
+	conn.Close()
+	time.Sleep(80 * time.Millisecond)
+	if b.isAttached("gone") {
+		t.Error("an expired session should be detached from the bridge")
+	}
+}
+
+// fakeNodeBridge records presence members and lets tests report dead
+// instances.
+type fakeNodeBridge struct {
+	*fakeDirectBridge
+	members map[string]bool // channel + "/" + client ID
+	down    []func(types.NodeDown)
+}
+
+func (b *fakeNodeBridge) AddMember(channel string, m types.Member) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.members[channel+"/"+m.ClientID] = true
+	return nil
+}
+
+func (b *fakeNodeBridge) RemoveMember(channel, clientID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.members, channel+"/"+clientID)
+	return nil
+}
+
+func (b *fakeNodeBridge) OnNodeDown(cb func(types.NodeDown)) {
+	b.down = append(b.down, cb)
+}
+
+func (b *fakeNodeBridge) isMember(channel, clientID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.members[channel+"/"+clientID]
+}
+
+func TestNodeDownRemovesPresenceMembers(t *testing.T) {
+	h := newTestHub(t)
+	b := &fakeNodeBridge{fakeDirectBridge: newFakeDirectBridge(), members: map[string]bool{}}
+	h.SetBridge(b)
+
+	var mu sync.Mutex
+	var reported []string
+	h.OnNodeDown(func(nodeID string, clients []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(append(reported, nodeID), clients...)
+	})
+
+	_, conn := registerClient(t, h, "watcher")
+	if err := h.SubscribeMember("presence-room", "watcher", nil); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if !b.isMember("presence-room", "watcher") {
+		t.Error("a local member should be recorded with the bridge")
+	}
+
+	for _, cb := range b.down {
+		cb(types.NodeDown{
+			NodeID:  "ghost",
+			Clients: []string{"c9"},
+			Members: map[string][]types.Member{"presence-room": {{ClientID: "c9", UserID: "u9"}}},
+		})
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	var removed []string
+	for _, msg := range eventsOn(conn, "presence-room") {
+		if msg.Event == types.EventMemberRemoved {
+			id, _ := msg.Data["client_id"].(string)
+			removed = append(removed, id)
+		}
+	}
+	if len(removed) != 1 || removed[0] != "c9" {
+		t.Errorf("expected member_removed for c9, got %v", removed)
+	}
+	mu.Lock()
+	if len(reported) != 2 || reported[0] != "ghost" || reported[1] != "c9" {
+		t.Errorf("expected the dead node and its clients, got %v", reported)
+	}
+	mu.Unlock()
+
+	if !h.Unsubscribe("presence-room", "watcher") {
+		t.Fatal("unsubscribe failed")
+	}
+	if b.isMember("presence-room", "watcher") {
+		t.Error("a member that left should be removed from the bridge")
+	}
+}
@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/hub"
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// frameConn is a mockConn that also accepts pre-encoded frames.
+type frameConn struct {
+	*mockConn
+	frameMu sync.Mutex
+	frames  []*types.Frame
+}
+
+func (f *frameConn) WriteFrame(fr *types.Frame) error {
+	f.frameMu.Lock()
+	defer f.frameMu.Unlock()
+	f.frames = append(f.frames, fr)
+	return nil
+}
+
+func (f *frameConn) getFrames() []*types.Frame {
+	f.frameMu.Lock()
+	defer f.frameMu.Unlock()
+	return append([]*types.Frame(nil), f.frames...)
+}
+
+// registerFrameClient registers a client whose connection writes frames.
+func registerFrameClient(t *testing.T, h *hub.Hub, id string) *frameConn {
+	t.Helper()
+	conn := &frameConn{mockConn: newMockConn()}
+	client := hub.NewClient(id, conn, h)
+	h.Register(client)
+	go client.WritePump()
+	time.Sleep(20 * time.Millisecond)
+	return conn
+}
+
+func TestBroadcastSharesOneFrame(t *testing.T) {
+	h := newTestHub(t)
+	conns := []*frameConn{
+		registerFrameClient(t, h, "f1"),
+		registerFrameClient(t, h, "f2"),
+		registerFrameClient(t, h, "f3"),
+	}
+	_, plain := registerClient(t, h, "plain")
+	for _, id := range []string{"f1", "f2", "f3", "plain"} {
+		h.Subscribe("prices", id)
+	}
+
+	h.Publish("prices", types.Message{Channel: "prices", Event: "tick", Data: map[string]any{"n": 1}})
+	time.Sleep(50 * time.Millisecond)
+
+	var first *types.Frame
+	for i, conn := range conns {
+		frames := conn.getFrames()
+		if len(frames) != 1 {
+			t.Fatalf("client %d: expected 1 frame, got %d", i, len(frames))
+		}
+		if first == nil {
+			first = frames[0]
+		} else if frames[0] != first {
+			t.Error("subscribers should share the same encoded frame")
+		}
+		if len(conn.getWritten()) != 0 {
+			t.Errorf("client %d: broadcast should not go through WriteJSON", i)
+		}
+	}
+
+	var decoded types.Message
+	if err := json.Unmarshal(first.Data, &decoded); err != nil {
+		t.Fatalf("frame is not a JSON message: %v", err)
+	}
+	if decoded.Channel != "prices" || decoded.Event != "tick" || decoded.Data["n"] != float64(1) {
+		t.Errorf("unexpected frame contents: %s", first.Data)
+	}
+
+	if msgs := eventsOn(plain, "prices"); len(msgs) != 1 || msgs[0].Event != "tick" {
+		t.Errorf("connections without WriteFrame should receive the message via WriteJSON, got %v", msgs)
+	}
+}
+
+func TestFramePreparedOnce(t *testing.T) {
+	frame, err := types.NewFrame(types.Message{Channel: "c", Event: "e"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	builds := 0
+	for range 3 {
+		v, err := frame.Prepared(func(data []byte) (any, error) {
+			builds++
+			return string(data), nil
+		})
+		if err != nil || v != string(frame.Data) {
+			t.Fatalf("unexpected prepared value %v, %v", v, err)
+		}
+	}
+	if builds != 1 {
+		t.Errorf("expected 1 build, got %d", builds)
+	}
+}
+
+func TestDirectMessageSkipsFrame(t *testing.T) {
+	h := newTestHub(t)
+	conn := registerFrameClient(t, h, "f1")
+
+	if err := h.Send("f1", types.Message{Channel: "inbox", Event: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if len(conn.getFrames()) != 0 {
+		t.Error("direct messages are not pre-encoded")
+	}
+	if msgs := eventsOn(conn.mockConn, "inbox"); len(msgs) != 1 {
+		t.Errorf("expected the direct message via WriteJSON, got %v", msgs)
+	}
+}
+
+func TestSendChannelCarriesMessages(t *testing.T) {
+	h, client := newStalledClient(t, "reader")
+	h.Subscribe("feed", "reader")
+
+	h.BroadcastToLocal(numbered(7))
+	select {
+	case msg := <-client.Send:
+		if msg.Event != "tick" || msg.Data["n"] != 7 {
+			t.Errorf("unexpected message %+v", msg)
+		}
+		if msg.Frame() == nil {
+			t.Error("a broadcast should carry its shared frame")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("broadcast never reached Send")
+	}
+}
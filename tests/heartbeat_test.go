@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/hub"
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// heartbeatConn extends mockConn with ping/pong and read deadlines.
+// When answerPings is set, every ping triggers the pong handler.
+type heartbeatConn struct {
+	*mockConn
+	answerPings bool
+
+	hbMu     sync.Mutex
+	pong     func() error
+	deadline time.Time
+	pings    int
+}
+
+func newHeartbeatConn(answerPings bool) *heartbeatConn {
+	return &heartbeatConn{mockConn: newMockConn(), answerPings: answerPings}
+}
+
+func (c *heartbeatConn) WritePing(time.Time) error {
+	c.hbMu.Lock()
+	c.pings++
+	pong := c.pong
+	c.hbMu.Unlock()
+	if c.answerPings && pong != nil {
+		return pong()
+	}
+	return nil
+}
+
+func (c *heartbeatConn) SetPongHandler(h func() error) {
+	c.hbMu.Lock()
+	defer c.hbMu.Unlock()
+	c.pong = h
+}
+
+func (c *heartbeatConn) SetReadDeadline(t time.Time) error {
+	c.hbMu.Lock()
+	defer c.hbMu.Unlock()
+	c.deadline = t
+	return nil
+}
+
+func (c *heartbeatConn) SetWriteDeadline(time.Time) error { return nil }
+
+func (c *heartbeatConn) pingCount() int {
+	c.hbMu.Lock()
+	defer c.hbMu.Unlock()
+	return c.pings
+}
+
+// ReadJSON returns a timeout error once the current read deadline passes.
+func (c *heartbeatConn) ReadJSON(v any) error {
+	for {
+		c.hbMu.Lock()
+		dl := c.deadline
+		c.hbMu.Unlock()
+		wait := 5 * time.Millisecond
+		if !dl.IsZero() && time.Now().After(dl) {
+			return &timeoutError{}
+		}
+		select {
+		case msg := <-c.readCh:
+			if ptr, ok := v.(*types.Message); ok {
+				*ptr = msg
+			}
+			return nil
+		case <-c.closedCh:
+			return &closeError{}
+		case <-time.After(wait):
+		}
+	}
+}
+
+type timeoutError struct{}
+
+func (e *timeoutError) Error() string   { return "i/o timeout" }
+func (e *timeoutError) Timeout() bool   { return true }
+func (e *timeoutError) Temporary() bool { return true }
+
+func startHeartbeatClient(t *testing.T, h *hub.Hub, id string, answer bool) *heartbeatConn {
+	t.Helper()
+	conn := newHeartbeatConn(answer)
+	client := hub.NewClient(id, conn, h)
+	h.Register(client)
+	go client.WritePump()
+	go client.ReadPump()
+	return conn
+}
+
+func TestHeartbeatReapsUnresponsiveClient(t *testing.T) {
+	h := newTestHub(t)
+	h.SetHeartbeat(20*time.Millisecond, 10*time.Millisecond)
+
+	reasons := make(chan types.DisconnectReason, 1)
+	h.OnDisconnection(func(_ string, r types.DisconnectReason) { reasons <- r })
+
+	startHeartbeatClient(t, h, "silent", false)
+
+	select {
+	case r := <-reasons:
+		if r != types.DisconnectTimeout {
+			t.Errorf("expected timeout reason, got %s", r)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("unresponsive client was not reaped")
+	}
+	if h.ClientInfo("silent") != nil {
+		t.Error("reaped client should be removed from the hub")
+	}
+}
+
+func TestHeartbeatKeepsResponsiveClient(t *testing.T) {
+	h := newTestHub(t)
+	h.SetHeartbeat(20*time.Millisecond, 10*time.Millisecond)
+
+	conn := startHeartbeatClient(t, h, "alive", true)
+	time.Sleep(150 * time.Millisecond)
+
+	if h.ClientInfo("alive") == nil {
+		t.Fatal("responsive client should stay connected")
+	}
+	if conn.pingCount() == 0 {
+		t.Error("expected pings to be sent")
+	}
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/hub"
+	"github.com/orchestra-mcp/socket/src/service"
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/rs/zerolog"
+)
+
+func TestHistoryRetainsLastN(t *testing.T) {
+	h := newTestHub(t)
+	svc := service.New(h, zerolog.Nop())
+	svc.SetChannelHistory("metrics-*", hub.HistoryPolicy{Limit: 3})
+
+	for i := 0; i < 5; i++ {
+		_ = svc.Publish("metrics-cpu", map[string]any{"n": i})
+	}
+	_ = svc.Publish("other", map[string]any{"n": 0})
+	time.Sleep(50 * time.Millisecond)
+
+	msgs := svc.History("metrics-cpu", hub.HistoryOptions{})
+	if len(msgs) != 3 || msgs[0].Data["n"] != 2 || msgs[2].Data["n"] != 4 {
+		t.Fatalf("expected messages 2..4, got %+v", msgs)
+	}
+	if last := svc.History("metrics-cpu", hub.HistoryOptions{Limit: 1}); len(last) != 1 || last[0].Data["n"] != 4 {
+		t.Errorf("expected last value 4, got %+v", last)
+	}
+	if other := svc.History("other", hub.HistoryOptions{}); len(other) != 0 {
+		t.Errorf("channels without a policy keep no history, got %d", len(other))
+	}
+}
+
+func TestHistoryMaxAge(t *testing.T) {
+	h := newTestHub(t)
+	h.SetChannelHistory("ticks", hub.HistoryPolicy{MaxAge: 40 * time.Millisecond})
+
+	h.Publish("ticks", types.Message{Channel: "ticks", Event: "old"})
+	time.Sleep(60 * time.Millisecond)
+	h.Publish("ticks", types.Message{Channel: "ticks", Event: "new"})
+	time.Sleep(20 * time.Millisecond)
+
+	msgs := h.History("ticks", hub.HistoryOptions{})
+	if len(msgs) != 1 || msgs[0].Event != "new" {
+		t.Errorf("expected only the new message, got %+v", msgs)
+	}
+}
+
+func TestHistoryRejectsUnboundedPolicy(t *testing.T) {
+	h := newTestHub(t)
+	for _, p := range []hub.HistoryPolicy{{}, {Limit: -1}, {MaxAge: -time.Second}} {
+		if err := h.SetChannelHistory("feed", p); err == nil {
+			t.Errorf("expected policy %+v to be rejected", p)
+		}
+	}
+	if err := h.SetChannelHistory("[", hub.HistoryPolicy{Limit: 1}); err == nil {
+		t.Error("expected a malformed pattern to be rejected")
+	}
+
+	h.Publish("feed", types.Message{Channel: "feed", Event: "a"})
+	time.Sleep(20 * time.Millisecond)
+	if msgs := h.History("feed", hub.HistoryOptions{}); len(msgs) != 0 {
+		t.Errorf("rejected policies must not retain messages, got %d", len(msgs))
+	}
+}
+
+func TestSubscribeDeliversHistory(t *testing.T) {
+	h := newTestHub(t)
+	h.SetChannelHistory("dash", hub.HistoryPolicy{Limit: 10})
+
+	for _, ev := range []string{"a", "b", "c"} {
+		h.Publish("dash", types.Message{Channel: "dash", Event: ev})
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	_, conn := connectClient(t, h, "viewer")
+	conn.readCh <- types.Message{
+		Channel: types.SystemChannel,
+		Event:   types.EventSubscribe,
+		Data:    map[string]any{"channel": "dash", "history": float64(2)},
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	got := eventsOn(conn, "dash")
+	if len(got) != 2 || got[0].Event != "b" || got[1].Event != "c" {
+		t.Errorf("expected last two messages b, c, got %+v", got)
+	}
+}
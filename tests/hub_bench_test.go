@@ -0,0 +1,214 @@
+package tests
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/hub"
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/rs/zerolog"
+)
+
+// countingConn encodes and discards writes, counting them, and reads from
+// a channel.
+type countingConn struct {
+	writes *atomic.Int64
+	reads  chan types.Message
+	closed chan struct{}
+}
+
+func newCountingConn(writes *atomic.Int64) *countingConn {
+	return &countingConn{writes: writes, reads: make(chan types.Message, 64), closed: make(chan struct{})}
+}
+
+func (c *countingConn) WriteJSON(v any) error {
+	if _, err := json.Marshal(v); err != nil {
+		return err
+	}
+	c.writes.Add(1)
+	return nil
+}
+
+func (c *countingConn) ReadJSON(v any) error {
+	select {
+	case msg := <-c.reads:
+		*v.(*types.Message) = msg
+		return nil
+	case <-c.closed:
+		return &closeError{}
+	}
+}
+
+func (c *countingConn) Close() error { return nil }
+
+// framingConn is a countingConn that also writes pre-encoded frames.
+type framingConn struct {
+	*countingConn
+}
+
+func (c framingConn) WriteFrame(*types.Frame) error {
+	c.writes.Add(1)
+	return nil
+}
+
+// shardCounts are the shard counts each benchmark compares. Run with
+// -cpu 1,2,4,8 to see throughput follow the cores available.
+var shardCounts = []int{1, 2, 4, 8}
+
+// benchSink keeps handler work from being optimized away.
+var benchSink atomic.Uint32
+
+func newBenchHub(b *testing.B, shards int) *hub.Hub {
+	b.Helper()
+	h := hub.New(zerolog.Nop(), hub.WithShards(shards), hub.WithSendBuffer(1024), hub.WithQueueSize(1024))
+	h.SetSlowConsumerPolicy(hub.SlowConsumerPolicy{Overflow: hub.OverflowBlock, Timeout: time.Minute})
+	go h.Run()
+	b.Cleanup(h.Stop)
+	return h
+}
+
+// startBenchClient registers a client on conn, starts its pumps and
+// waits until the hub has added it.
+func startBenchClient(b *testing.B, h *hub.Hub, id string, conn types.Conn) {
+	b.Helper()
+	c := hub.NewClient(id, conn, h)
+	h.Register(c)
+	go c.WritePump()
+	go c.ReadPump()
+	for h.ClientInfo(id) == nil {
+		runtime.Gosched()
+	}
+}
+
+// waitFor spins until counter reaches want.
+func waitFor(counter *atomic.Int64, want int64) {
+	for counter.Load() < want {
+		runtime.Gosched()
+	}
+}
+
+// BenchmarkHubBroadcast publishes to 64 channels of 8 subscribers each
+// from parallel publishers. One op is one message fanned out to 8 clients.
+func BenchmarkHubBroadcast(b *testing.B) {
+	const channels, perChannel = 64, 8
+	for _, shards := range shardCounts {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			h := newBenchHub(b, shards)
+			var writes atomic.Int64
+			var conns []*countingConn
+			for ch := range channels {
+				for i := range perChannel {
+					id := fmt.Sprintf("c%d-%d", ch, i)
+					conn := newCountingConn(&writes)
+					conns = append(conns, conn)
+					startBenchClient(b, h, id, conn)
+					h.Subscribe(fmt.Sprint(ch), id)
+				}
+			}
+			b.Cleanup(func() {
+				for _, c := range conns {
+					close(c.closed)
+				}
+			})
+
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					ch := fmt.Sprint(next.Add(1) % channels)
+					h.Publish(ch, types.Message{Channel: ch, Event: "tick"})
+				}
+			})
+			waitFor(&writes, int64(b.N)*perChannel)
+			b.ReportMetric(float64(b.N*perChannel)/b.Elapsed().Seconds(), "deliveries/s")
+		})
+	}
+}
+
+// BenchmarkHubBroadcastEncoding publishes to one channel of 1000
+// subscribers. With conn=json every client marshals the message itself;
+// with conn=frame the hub encodes it once and the clients share the frame.
+func BenchmarkHubBroadcastEncoding(b *testing.B) {
+	const subscribers = 1000
+	data := map[string]any{"symbol": "ACME", "price": 101.25, "levels": []int{1, 2, 3, 4, 5, 6, 7, 8}}
+	for _, framed := range []bool{false, true} {
+		name := "conn=json"
+		if framed {
+			name = "conn=frame"
+		}
+		b.Run(name, func(b *testing.B) {
+			h := newBenchHub(b, 1)
+			var writes atomic.Int64
+			conns := make([]*countingConn, subscribers)
+			for i := range conns {
+				id := fmt.Sprintf("c%d", i)
+				conns[i] = newCountingConn(&writes)
+				var conn types.Conn = conns[i]
+				if framed {
+					conn = framingConn{conns[i]}
+				}
+				startBenchClient(b, h, id, conn)
+				h.Subscribe("quotes", id)
+			}
+			b.Cleanup(func() {
+				for _, c := range conns {
+					close(c.closed)
+				}
+			})
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				h.Publish("quotes", types.Message{Channel: "quotes", Event: "tick", Data: data})
+			}
+			waitFor(&writes, int64(b.N)*subscribers)
+			b.ReportMetric(float64(b.N*subscribers)/b.Elapsed().Seconds(), "deliveries/s")
+		})
+	}
+}
+
+// BenchmarkHubInbound sends client messages to a handler doing about 10µs
+// of CPU work. One op is one handled message.
+func BenchmarkHubInbound(b *testing.B) {
+	const clients = 256
+	for _, shards := range shardCounts {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			h := newBenchHub(b, shards)
+			var handled atomic.Int64
+			payload := make([]byte, 4096)
+			h.RegisterHandler("work", func(string, types.Message) error {
+				sum := sha256.Sum256(payload)
+				benchSink.Store(uint32(sum[0]))
+				handled.Add(1)
+				return nil
+			})
+
+			var writes atomic.Int64
+			conns := make([]*countingConn, clients)
+			for i := range conns {
+				conns[i] = newCountingConn(&writes)
+				startBenchClient(b, h, fmt.Sprintf("c%d", i), conns[i])
+			}
+			b.Cleanup(func() {
+				for _, c := range conns {
+					close(c.closed)
+				}
+			})
+
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					conns[next.Add(1)%clients].reads <- types.Message{Channel: "work"}
+				}
+			})
+			waitFor(&handled, int64(b.N))
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "msgs/s")
+		})
+	}
+}
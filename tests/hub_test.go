@@ -211,24 +211,42 @@ func TestSendToClient(t *testing.T) {
 func TestConnectionCallbacks(t *testing.T) {
 	h := newTestHub(t)
 
+	var mu sync.Mutex
 	var connectedID string
 	var disconnectedID string
-	h.OnConnection(func(id string) { connectedID = id })
-	h.OnDisconnection(func(id string) { disconnectedID = id })
+	var reason types.DisconnectReason
+	h.OnConnection(func(id string) {
+		mu.Lock()
+		defer mu.Unlock()
+		connectedID = id
+	})
+	h.OnDisconnection(func(id string, r types.DisconnectReason) {
+		mu.Lock()
+		defer mu.Unlock()
+		disconnectedID = id
+		reason = r
+	})
 
 	client, _ := registerClient(t, h, "cb-client")
 	time.Sleep(20 * time.Millisecond)
 
+	mu.Lock()
 	if connectedID != "cb-client" {
 		t.Errorf("expected connected callback with cb-client, got %s", connectedID)
 	}
+	mu.Unlock()
 
 	h.Unregister(client)
 	time.Sleep(20 * time.Millisecond)
 
+	mu.Lock()
+	defer mu.Unlock()
 	if disconnectedID != "cb-client" {
 		t.Errorf("expected disconnected callback with cb-client, got %s", disconnectedID)
 	}
+	if reason != types.DisconnectServer {
+		t.Errorf("expected reason %s, got %s", types.DisconnectServer, reason)
+	}
 }
 
 func TestClientInfo(t *testing.T) {
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/orchestra-mcp/socket/src/hub"
+)
+
+func TestAdmitEnforcesGlobalLimit(t *testing.T) {
+	h := newTestHub(t)
+	h.SetLimits(hub.Limits{MaxConnections: 2})
+
+	if err := h.Admit("10.0.0.1", ""); err != nil {
+		t.Fatalf("first admit: %v", err)
+	}
+	if err := h.Admit("10.0.0.2", ""); err != nil {
+		t.Fatalf("second admit: %v", err)
+	}
+
+	var le *hub.LimitError
+	if err := h.Admit("10.0.0.3", ""); !errors.As(err, &le) || le.Scope != hub.LimitGlobal {
+		t.Fatalf("expected global limit error, got %v", err)
+	}
+
+	h.Release("10.0.0.1", "")
+	if err := h.Admit("10.0.0.3", ""); err != nil {
+		t.Errorf("admit after release should succeed: %v", err)
+	}
+	if got := h.Stats().Rejected[hub.LimitGlobal]; got != 1 {
+		t.Errorf("expected 1 global rejection, got %d", got)
+	}
+}
+
+func TestAdmitEnforcesPerIPAndPerUserLimits(t *testing.T) {
+	h := newTestHub(t)
+	h.SetLimits(hub.Limits{MaxPerIP: 1, MaxPerUser: 1})
+
+	if err := h.Admit("10.0.0.1", "u1"); err != nil {
+		t.Fatalf("first admit: %v", err)
+	}
+
+	var le *hub.LimitError
+	if err := h.Admit("10.0.0.1", "u2"); !errors.As(err, &le) || le.Scope != hub.LimitIP {
+		t.Errorf("expected ip limit error, got %v", err)
+	}
+	if err := h.Admit("10.0.0.2", "u1"); !errors.As(err, &le) || le.Scope != hub.LimitUser {
+		t.Errorf("expected user limit error, got %v", err)
+	}
+	if err := h.Admit("10.0.0.2", "u2"); err != nil {
+		t.Errorf("different ip and user should be admitted: %v", err)
+	}
+
+	stats := h.Stats()
+	if stats.Rejected[hub.LimitIP] != 1 || stats.Rejected[hub.LimitUser] != 1 {
+		t.Errorf("unexpected rejection stats: %+v", stats.Rejected)
+	}
+}
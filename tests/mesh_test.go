@@ -0,0 +1,250 @@
+package tests
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/bridge"
+	"github.com/orchestra-mcp/socket/src/hub"
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/rs/zerolog"
+)
+
+// newMeshHub starts a hub joined to mesh as node id.
+func newMeshHub(t *testing.T, mesh *bridge.Mesh, id string) *hub.Hub {
+	t.Helper()
+	h := newTestHub(t)
+	b := bridge.NewMemoryBridge(mesh, id, h, zerolog.Nop())
+	if err := b.Start(); err != nil {
+		t.Fatalf("start %s: %v", id, err)
+	}
+	t.Cleanup(func() { _ = b.Stop() })
+	h.SetBridge(b)
+	return h
+}
+
+// settle waits for in-flight mesh deliveries and the hubs processing them.
+func settle(mesh *bridge.Mesh) {
+	time.Sleep(20 * time.Millisecond)
+	mesh.Wait()
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestMeshRelaysBroadcasts(t *testing.T) {
+	mesh := bridge.NewMesh(1)
+	a := newMeshHub(t, mesh, "a")
+	b := newMeshHub(t, mesh, "b")
+	c := newMeshHub(t, mesh, "c")
+
+	_, connB := registerClient(t, b, "on-b")
+	b.Subscribe("news", "on-b")
+	_, connC := registerClient(t, c, "on-c")
+	c.Subscribe("other", "on-c")
+
+	a.Publish("news", types.Message{Channel: "news", Event: "headline"})
+	settle(mesh)
+
+	if len(connB.getWritten()) != 1 {
+		t.Errorf("subscriber on b should get the message, got %d", len(connB.getWritten()))
+	}
+	if len(connC.getWritten()) != 0 {
+		t.Errorf("c has no interest in the channel, got %d", len(connC.getWritten()))
+	}
+}
+
+func TestMeshPartition(t *testing.T) {
+	mesh := bridge.NewMesh(1)
+	a := newMeshHub(t, mesh, "a")
+	b := newMeshHub(t, mesh, "b")
+
+	_, conn := registerClient(t, b, "on-b")
+	b.Subscribe("news", "on-b")
+
+	mesh.Partition("a", "b")
+	a.Publish("news", types.Message{Channel: "news", Event: "lost"})
+	settle(mesh)
+	if len(conn.getWritten()) != 0 {
+		t.Fatalf("partitioned node should not receive, got %d", len(conn.getWritten()))
+	}
+
+	mesh.Heal("a", "b")
+	a.Publish("news", types.Message{Channel: "news", Event: "found"})
+	settle(mesh)
+	if len(conn.getWritten()) != 1 {
+		t.Errorf("healed node should receive, got %d", len(conn.getWritten()))
+	}
+}
+
+func TestMeshLossIsDeterministic(t *testing.T) {
+	received := func() int {
+		mesh := bridge.NewMesh(42)
+		mesh.SetLoss(0.5)
+		a := newMeshHub(t, mesh, "a")
+		b := newMeshHub(t, mesh, "b")
+		_, conn := registerClient(t, b, "on-b")
+		b.Subscribe("news", "on-b")
+
+		for range 20 {
+			a.Publish("news", types.Message{Channel: "news", Event: "tick"})
+		}
+		settle(mesh)
+		return len(conn.getWritten())
+	}
+
+	first := received()
+	if first == 0 || first == 20 {
+		t.Fatalf("50%% loss should drop some but not all messages, got %d of 20", first)
+	}
+	if second := received(); second != first {
+		t.Errorf("same seed should drop the same messages: %d then %d", first, second)
+	}
+}
+
+func TestMeshLossIsDeterministicAcrossNodes(t *testing.T) {
+	received := func() []string {
+		mesh := bridge.NewMesh(7)
+		mesh.SetLoss(0.5)
+		a := newMeshHub(t, mesh, "a")
+		var conns []*mockConn
+		for _, id := range []string{"b", "c", "d"} {
+			h := newMeshHub(t, mesh, id)
+			_, conn := registerClient(t, h, "on-"+id)
+			h.Subscribe("news", "on-"+id)
+			conns = append(conns, conn)
+		}
+
+		for i := range 30 {
+			a.Publish("news", types.Message{Channel: "news", Event: fmt.Sprint(i)})
+		}
+		settle(mesh)
+		var got []string
+		for _, conn := range conns {
+			var events []string
+			for _, msg := range eventsOn(conn, "news") {
+				events = append(events, msg.Event)
+			}
+			got = append(got, strings.Join(events, ","))
+		}
+		return got
+	}
+
+	first := received()
+	for range 3 {
+		if again := received(); !reflect.DeepEqual(again, first) {
+			t.Fatalf("same seed should drop the same messages on every node:\n%v\n%v", first, again)
+		}
+	}
+}
+
+func TestMeshLatencyKeepsOrder(t *testing.T) {
+	mesh := bridge.NewMesh(1)
+	mesh.SetLatency(5 * time.Millisecond)
+	a := newMeshHub(t, mesh, "a")
+	b := newMeshHub(t, mesh, "b")
+	_, conn := registerClient(t, b, "on-b")
+	b.Subscribe("news", "on-b")
+
+	for i := range 100 {
+		a.Publish("news", types.Message{Channel: "news", Event: fmt.Sprint(i)})
+	}
+	settle(mesh)
+
+	msgs := eventsOn(conn, "news")
+	if len(msgs) != 100 {
+		t.Fatalf("expected 100 messages, got %d", len(msgs))
+	}
+	for i, msg := range msgs {
+		if msg.Event != fmt.Sprint(i) {
+			t.Fatalf("message %s delivered at position %d", msg.Event, i)
+		}
+	}
+}
+
+func TestMeshLatency(t *testing.T) {
+	mesh := bridge.NewMesh(1)
+	mesh.SetLatency(100 * time.Millisecond)
+	a := newMeshHub(t, mesh, "a")
+	b := newMeshHub(t, mesh, "b")
+
+	_, conn := registerClient(t, b, "on-b")
+	b.Subscribe("news", "on-b")
+
+	a.Publish("news", types.Message{Channel: "news", Event: "slow"})
+	time.Sleep(40 * time.Millisecond)
+	if len(conn.getWritten()) != 0 {
+		t.Fatal("message arrived before the injected latency")
+	}
+	settle(mesh)
+	if len(conn.getWritten()) != 1 {
+		t.Errorf("message should arrive after the latency, got %d", len(conn.getWritten()))
+	}
+}
+
+func TestMeshRoutesDirectMessages(t *testing.T) {
+	mesh := bridge.NewMesh(1)
+	a := newMeshHub(t, mesh, "a")
+	b := newMeshHub(t, mesh, "b")
+	_, conn := registerClient(t, b, "on-b")
+
+	msg := types.Message{Channel: "dm", Event: "hi"}
+	if where, err := a.Route("on-b", msg); err != nil || where != hub.DeliveredRemote {
+		t.Fatalf("expected remote delivery, got %v, %v", where, err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if len(conn.getWritten()) != 1 {
+		t.Errorf("direct message should reach the client on b, got %d", len(conn.getWritten()))
+	}
+
+	mesh.Partition("a", "b")
+	if _, err := a.Route("on-b", msg); err == nil {
+		t.Error("a partitioned client should not be reachable")
+	}
+}
+
+func TestMeshClusterStats(t *testing.T) {
+	mesh := bridge.NewMesh(1)
+	a := newMeshHub(t, mesh, "a")
+	b := newMeshHub(t, mesh, "b")
+	_, _ = registerClient(t, a, "one")
+	_, _ = registerClient(t, b, "two")
+	b.Subscribe("news", "two")
+
+	stats, err := a.ClusterStats()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stats.Nodes) != 2 || stats.Clients != 2 || stats.Channels["news"] != 1 {
+		t.Errorf("bad cluster stats: %+v", stats)
+	}
+}
+
+func TestMeshLocateAndKick(t *testing.T) {
+	mesh := bridge.NewMesh(1)
+	a := newMeshHub(t, mesh, "a")
+	b := newMeshHub(t, mesh, "b")
+	_, _ = registerClient(t, a, "on-a")
+	_, _ = registerClient(t, b, "on-b")
+
+	loc, err := a.LocateClient("on-b")
+	if err != nil || loc.InstanceID != "b" || loc.Local {
+		t.Fatalf("expected on-b on node b, got %+v, %v", loc, err)
+	}
+	loc, err = a.LocateClient("on-a")
+	if err != nil || loc.InstanceID != "a" || !loc.Local {
+		t.Fatalf("expected on-a local on node a, got %+v, %v", loc, err)
+	}
+
+	if where, err := a.Kick("on-b"); err != nil || where != hub.DeliveredRemote {
+		t.Fatalf("expected a remote kick, got %v, %v", where, err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if b.ClientInfo("on-b") != nil {
+		t.Error("kicked client should be removed from b")
+	}
+	if _, err := a.LocateClient("on-b"); err == nil {
+		t.Error("a kicked client should no longer be located")
+	}
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/orchestra-mcp/socket/src/auth"
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+func TestPolicyPrivateChannelsRequireIdentity(t *testing.T) {
+	p := auth.NewPolicy(false).Allow("private-*")
+
+	anon := types.ClientInfo{ID: "c1"}
+	if err := p.Authorize(anon, "private-doc", auth.ActionSubscribe); !errors.Is(err, auth.ErrForbidden) {
+		t.Errorf("anonymous client should be denied private channel, got %v", err)
+	}
+	user := types.ClientInfo{ID: "c2", UserID: "u1"}
+	if err := p.Authorize(user, "private-doc", auth.ActionSubscribe); err != nil {
+		t.Errorf("authenticated client should be allowed, got %v", err)
+	}
+	if err := p.Authorize(user, "presence-room", auth.ActionSubscribe); err == nil {
+		t.Error("presence channel without a rule should be denied")
+	}
+	if err := p.Authorize(anon, "public", auth.ActionSubscribe); err != nil {
+		t.Errorf("public channel should be allowed by default, got %v", err)
+	}
+}
+
+func TestPolicyDefaultDenyAndActions(t *testing.T) {
+	p := auth.NewPolicy(true).
+		Deny("feed-*", auth.ActionPublish).
+		Allow("feed-*")
+
+	client := types.ClientInfo{ID: "c1"}
+	if err := p.Authorize(client, "feed-news", auth.ActionSubscribe); err != nil {
+		t.Errorf("subscribe should be allowed, got %v", err)
+	}
+	if err := p.Authorize(client, "feed-news", auth.ActionPublish); err == nil {
+		t.Error("publish should be denied")
+	}
+	if err := p.Authorize(client, "other", auth.ActionSubscribe); err == nil {
+		t.Error("unmatched channel should be denied in default-deny mode")
+	}
+}
+
+// tenantPolicy allows "tenant-<id>-*" only for clients whose tenant claim matches.
+func tenantPolicy() *auth.Policy {
+	return auth.NewPolicy(true).AllowIf("tenant-*", func(c types.ClientInfo, channel string) bool {
+		tenant, _ := c.Claims["tenant"].(string)
+		return tenant != "" && strings.HasPrefix(channel, "tenant-"+tenant+"-")
+	})
+}
+
+func TestHubEnforcesChannelAuthorizer(t *testing.T) {
+	h := newTestHub(t)
+	h.SetChannelAuthorizer(tenantPolicy())
+
+	client, _ := registerClient(t, h, "acme-user")
+	client.SetIdentity(types.Identity{UserID: "u1", Claims: map[string]any{"tenant": "acme"}})
+
+	if !h.Subscribe("tenant-acme-events", "acme-user") {
+		t.Error("client should join its own tenant channel")
+	}
+	if h.Subscribe("tenant-globex-events", "acme-user") {
+		t.Error("client must not join another tenant's channel")
+	}
+	if err := h.SubscribeClient("tenant-globex-events", "acme-user"); !errors.Is(err, auth.ErrForbidden) {
+		t.Errorf("expected forbidden error, got %v", err)
+	}
+}
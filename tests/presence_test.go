@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/service"
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/rs/zerolog"
+)
+
+// eventsOn returns the events written to conn on the given channel, in order.
+func eventsOn(conn *mockConn, channel string) []types.Message {
+	var out []types.Message
+	for _, w := range conn.getWritten() {
+		if msg, ok := w.(types.Message); ok && msg.Channel == channel {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+func TestPresenceJoinAndLeave(t *testing.T) {
+	h := newTestHub(t)
+	svc := service.New(h, zerolog.Nop())
+
+	alice, aliceConn := registerClient(t, h, "alice")
+	alice.SetIdentity(types.Identity{UserID: "u-alice"})
+	bob, bobConn := registerClient(t, h, "bob")
+	bob.SetIdentity(types.Identity{UserID: "u-bob"})
+
+	if err := h.SubscribeMember("presence-room", "alice", map[string]any{"name": "Alice"}); err != nil {
+		t.Fatalf("alice subscribe: %v", err)
+	}
+	if err := h.SubscribeMember("presence-room", "bob", nil); err != nil {
+		t.Fatalf("bob subscribe: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	members, err := svc.GetPresence("presence-room")
+	if err != nil {
+		t.Fatalf("get presence: %v", err)
+	}
+	if len(members) != 2 || members[0].UserID != "u-alice" || members[0].Info["name"] != "Alice" {
+		t.Fatalf("unexpected members: %+v", members)
+	}
+
+	bobEvents := eventsOn(bobConn, "presence-room")
+	if len(bobEvents) != 1 || bobEvents[0].Event != types.EventMembers {
+		t.Fatalf("bob should receive the member list, got %+v", bobEvents)
+	}
+	if list, _ := bobEvents[0].Data["members"].([]types.Member); len(list) != 2 {
+		t.Errorf("expected 2 members in list, got %d", len(list))
+	}
+
+	aliceEvents := eventsOn(aliceConn, "presence-room")
+	if len(aliceEvents) != 2 || aliceEvents[1].Event != types.EventMemberAdded || aliceEvents[1].Data["user_id"] != "u-bob" {
+		t.Fatalf("alice should see bob join, got %+v", aliceEvents)
+	}
+
+	h.Unregister(bob)
+	time.Sleep(50 * time.Millisecond)
+
+	aliceEvents = eventsOn(aliceConn, "presence-room")
+	last := aliceEvents[len(aliceEvents)-1]
+	if last.Event != types.EventMemberRemoved || last.Data["client_id"] != "bob" {
+		t.Errorf("alice should see bob leave, got %+v", last)
+	}
+	if members, _ := svc.GetPresence("presence-room"); len(members) != 1 {
+		t.Errorf("expected 1 member after leave, got %d", len(members))
+	}
+}
+
+func TestGetPresenceRejectsRegularChannel(t *testing.T) {
+	h := newTestHub(t)
+	svc := service.New(h, zerolog.Nop())
+
+	if _, err := svc.GetPresence("news"); err == nil {
+		t.Error("expected error for non-presence channel")
+	}
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/hub"
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// systemEvents returns the system-channel frames with the given event.
+func systemEvents(conn *mockConn, event string) []types.Message {
+	var out []types.Message
+	for _, msg := range eventsOn(conn, types.SystemChannel) {
+		if msg.Event == event {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+func TestRequestHandlerReplies(t *testing.T) {
+	h := newTestHub(t)
+	h.RegisterRequestHandler("orders", func(_ context.Context, req *hub.Request) error {
+		return req.Reply(map[string]any{
+			"user":  req.Identity.UserID,
+			"order": req.Message.Data["order"],
+		})
+	})
+
+	client, conn := connectClient(t, h, "c1")
+	client.SetIdentity(types.Identity{UserID: "u-1"})
+	conn.readCh <- types.Message{ID: "req-1", Channel: "orders", Event: "get", Data: map[string]any{"order": "o-9"}}
+	waitUntil(t, func() bool { return len(eventsOn(conn, "orders")) == 1 }, "the reply")
+
+	reply := eventsOn(conn, "orders")[0]
+	if reply.Event != types.EventReply || reply.ID != "req-1" {
+		t.Errorf("reply should echo the request ID, got %+v", reply)
+	}
+	if reply.Data["user"] != "u-1" || reply.Data["order"] != "o-9" {
+		t.Errorf("unexpected reply data %v", reply.Data)
+	}
+}
+
+func TestRequestHandlerErrorFrame(t *testing.T) {
+	h := newTestHub(t)
+	h.RegisterRequestHandler("orders", func(_ context.Context, req *hub.Request) error {
+		if req.Message.Event == "missing" {
+			return &hub.HandlerError{Code: "not_found", Message: "no such order"}
+		}
+		return errors.New("database unavailable")
+	})
+
+	_, conn := connectClient(t, h, "c1")
+	conn.readCh <- types.Message{ID: "a", Channel: "orders", Event: "missing"}
+	conn.readCh <- types.Message{ID: "b", Channel: "orders", Event: "get"}
+	waitUntil(t, func() bool { return len(systemEvents(conn, types.EventError)) == 2 }, "two error frames")
+
+	frames := systemEvents(conn, types.EventError)
+	want := []struct{ id, event, code, msg string }{
+		{"a", "missing", "not_found", "no such order"},
+		{"b", "get", hub.CodeHandlerError, "database unavailable"},
+	}
+	for i, w := range want {
+		f := frames[i]
+		if f.ID != w.id || f.Data["event"] != w.event || f.Data["channel"] != "orders" ||
+			f.Data["code"] != w.code || f.Data["error"] != w.msg {
+			t.Errorf("frame %d: unexpected %+v", i, f)
+		}
+	}
+}
+
+func TestRequestReplyErrorIsNotRepeated(t *testing.T) {
+	h := newTestHub(t)
+	h.RegisterRequestHandler("orders", func(_ context.Context, req *hub.Request) error {
+		err := &hub.HandlerError{Code: "invalid", Message: "bad order"}
+		_ = req.ReplyError(err)
+		return err
+	})
+
+	_, conn := connectClient(t, h, "c1")
+	conn.readCh <- types.Message{ID: "x", Channel: "orders"}
+	waitUntil(t, func() bool { return h.Stats().Handlers.Failed == 1 }, "the handler")
+	time.Sleep(20 * time.Millisecond)
+
+	if frames := systemEvents(conn, types.EventError); len(frames) != 1 || frames[0].Data["code"] != "invalid" {
+		t.Errorf("expected a single error frame, got %v", frames)
+	}
+}
+
+func TestRequestHandlerTimeoutFrame(t *testing.T) {
+	h := newPoolHub(t, 1, hub.WithHandlerTimeout(20*time.Millisecond))
+	h.RegisterRequestHandler("slow", func(ctx context.Context, _ *hub.Request) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	_, conn := connectClient(t, h, "c1")
+	conn.readCh <- types.Message{ID: "t1", Channel: "slow"}
+	waitUntil(t, func() bool { return len(systemEvents(conn, types.EventError)) == 1 }, "the timeout frame")
+
+	if f := systemEvents(conn, types.EventError)[0]; f.ID != "t1" || f.Data["code"] != hub.CodeTimeout {
+		t.Errorf("unexpected timeout frame %+v", f)
+	}
+}
+
+func TestRequestContextCancelledOnDisconnect(t *testing.T) {
+	h := newTestHub(t)
+	cancelled := make(chan error, 1)
+	h.RegisterRequestHandler("wait", func(ctx context.Context, _ *hub.Request) error {
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return ctx.Err()
+	})
+
+	client, conn := connectClient(t, h, "c1")
+	conn.readCh <- types.Message{Channel: "wait"}
+	waitUntil(t, func() bool { return h.Stats().Handlers.Active == 1 }, "the handler to start")
+	h.Unregister(client)
+
+	select {
+	case err := <-cancelled:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("disconnecting should cancel the handler's context")
+	}
+}
+
+func TestPlainHandlerErrorsAreNotSent(t *testing.T) {
+	h := newTestHub(t)
+	h.RegisterHandler("legacy", func(string, types.Message) error {
+		return errors.New("boom")
+	})
+
+	_, conn := connectClient(t, h, "c1")
+	conn.readCh <- types.Message{Channel: "legacy"}
+	waitUntil(t, func() bool { return h.Stats().Handlers.Failed == 1 }, "the handler")
+	time.Sleep(20 * time.Millisecond)
+
+	if frames := systemEvents(conn, types.EventError); len(frames) != 0 {
+		t.Errorf("MessageHandler errors should only be logged, got %v", frames)
+	}
+}
@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/auth"
+	"github.com/orchestra-mcp/socket/src/hub"
+	"github.com/orchestra-mcp/socket/src/service"
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/rs/zerolog"
+)
+
+// rpcFrame builds a client rpc frame.
+func rpcFrame(id, method string, params any) types.Message {
+	return types.Message{
+		ID:      id,
+		Channel: types.SystemChannel,
+		Event:   types.EventRPC,
+		Data:    map[string]any{"method": method, "params": params},
+	}
+}
+
+// awaitResult waits for the rpc_result answering id.
+func awaitResult(t *testing.T, conn *mockConn, id string) types.Message {
+	t.Helper()
+	var res types.Message
+	waitUntil(t, func() bool {
+		for _, msg := range systemEvents(conn, types.EventRPCResult) {
+			if msg.ID == id {
+				res = msg
+				return true
+			}
+		}
+		return false
+	}, "rpc result "+id)
+	return res
+}
+
+func TestRPCCallsServiceMethod(t *testing.T) {
+	h := newTestHub(t)
+	svc := service.New(h, zerolog.Nop())
+	svc.RegisterMethod("math.add", func(_ context.Context, call *hub.Call) (any, error) {
+		var p struct{ A, B int }
+		if err := call.Bind(&p); err != nil {
+			return nil, err
+		}
+		return map[string]any{"sum": p.A + p.B, "user": call.Identity.UserID}, nil
+	})
+
+	client, conn := connectClient(t, h, "c1")
+	client.SetIdentity(types.Identity{UserID: "u-1"})
+	conn.readCh <- rpcFrame("1", "math.add", map[string]any{"a": 2, "b": 3})
+
+	res := awaitResult(t, conn, "1")
+	result, _ := res.Data["result"].(map[string]any)
+	if res.Data["method"] != "math.add" || result["sum"] != 5 || result["user"] != "u-1" {
+		t.Errorf("unexpected result %+v", res)
+	}
+	if _, failed := res.Data["error"]; failed {
+		t.Errorf("successful call should carry no error: %+v", res)
+	}
+}
+
+func TestRPCErrors(t *testing.T) {
+	h := newTestHub(t)
+	h.RegisterMethod("orders.get", func(_ context.Context, call *hub.Call) (any, error) {
+		var p struct{ Order string }
+		if err := call.Bind(&p); err != nil {
+			return nil, err
+		}
+		if p.Order == "" {
+			return nil, errors.New("database unavailable")
+		}
+		return nil, &hub.HandlerError{Code: "not_found", Message: "no such order"}
+	})
+
+	_, conn := connectClient(t, h, "c1")
+	conn.readCh <- rpcFrame("1", "orders.get", map[string]any{"order": "o-1"})
+	conn.readCh <- rpcFrame("2", "orders.get", nil)
+	conn.readCh <- rpcFrame("3", "orders.get", "not an object")
+	conn.readCh <- rpcFrame("4", "orders.delete", nil)
+	conn.readCh <- rpcFrame("", "orders.get", nil)
+
+	for id, code := range map[string]string{
+		"1": "not_found",
+		"2": hub.CodeHandlerError,
+		"3": hub.CodeInvalidParams,
+		"4": hub.CodeMethodNotFound,
+		"":  hub.CodeInvalidRequest,
+	} {
+		if res := awaitResult(t, conn, id); res.Data["code"] != code || res.Data["error"] == "" {
+			t.Errorf("call %q: expected code %s, got %+v", id, code, res.Data)
+		}
+	}
+}
+
+func TestRPCTimeout(t *testing.T) {
+	h := newTestHub(t)
+	h.RegisterMethod("slow", func(ctx context.Context, _ *hub.Call) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	_, conn := connectClient(t, h, "c1")
+	frame := rpcFrame("1", "slow", nil)
+	frame.Data["timeout_ms"] = float64(20)
+	start := time.Now()
+	conn.readCh <- frame
+
+	res := awaitResult(t, conn, "1")
+	if res.Data["code"] != hub.CodeTimeout {
+		t.Errorf("expected a timeout, got %+v", res.Data)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("timeout_ms should shorten the call, took %v", elapsed)
+	}
+}
+
+func TestRPCAuthorization(t *testing.T) {
+	h := newTestHub(t)
+	h.SetChannelAuthorizer(auth.NewPolicy(false).Deny(hub.RPCChannelPrefix+"admin.*", auth.ActionInvoke))
+	h.RegisterMethod("admin.reset", func(context.Context, *hub.Call) (any, error) {
+		t.Error("a forbidden method must not run")
+		return nil, nil
+	})
+
+	_, conn := connectClient(t, h, "c1")
+	conn.readCh <- rpcFrame("1", "admin.reset", nil)
+	if res := awaitResult(t, conn, "1"); res.Data["code"] != hub.CodeForbidden {
+		t.Errorf("expected forbidden, got %+v", res.Data)
+	}
+}
+
+// answerCalls replies to server rpc frames on conn with respond.
+func answerCalls(conn *mockConn, respond func(call types.Message) map[string]any) {
+	seen := make(map[string]bool)
+	for {
+		select {
+		case <-conn.closedCh:
+			return
+		case <-time.After(5 * time.Millisecond):
+		}
+		for _, call := range systemEvents(conn, types.EventRPC) {
+			if seen[call.ID] {
+				continue
+			}
+			seen[call.ID] = true
+			conn.readCh <- types.Message{
+				ID:      call.ID,
+				Channel: types.SystemChannel,
+				Event:   types.EventRPCResult,
+				Data:    respond(call),
+			}
+		}
+	}
+}
+
+func TestServerCallsClient(t *testing.T) {
+	h := newTestHub(t)
+	_, conn := connectClient(t, h, "c1")
+	go answerCalls(conn, func(call types.Message) map[string]any {
+		if call.Data["method"] == "confirm" {
+			params, _ := call.Data["params"].(map[string]any)
+			return map[string]any{"result": params["question"] == "ok?"}
+		}
+		return map[string]any{"code": "method_not_found", "error": "unknown"}
+	})
+
+	ctx := context.Background()
+	result, err := h.Call(ctx, "c1", "confirm", map[string]any{"question": "ok?"})
+	if err != nil || result != true {
+		t.Fatalf("expected true, got %v, %v", result, err)
+	}
+
+	_, err = h.Call(ctx, "c1", "missing", nil)
+	var he *hub.HandlerError
+	if !errors.As(err, &he) || he.Code != "method_not_found" {
+		t.Errorf("expected the client's error, got %v", err)
+	}
+
+	if _, err := h.Call(ctx, "nobody", "confirm", nil); !errors.Is(err, hub.ErrClientNotFound) {
+		t.Errorf("expected ErrClientNotFound, got %v", err)
+	}
+}
+
+func TestServerCallEnds(t *testing.T) {
+	h := hub.New(zerolog.Nop(), hub.WithRPCTimeout(30*time.Millisecond))
+	go h.Run()
+	t.Cleanup(h.Stop)
+	client, conn := connectClient(t, h, "c1")
+	_, other := connectClient(t, h, "c2")
+
+	// Another client cannot answer a call made to c1.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		if calls := systemEvents(conn, types.EventRPC); len(calls) == 1 {
+			other.readCh <- types.Message{ID: calls[0].ID, Channel: types.SystemChannel,
+				Event: types.EventRPCResult, Data: map[string]any{"result": "spoofed"}}
+		}
+	}()
+	if _, err := h.Call(context.Background(), "c1", "confirm", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the RPC timeout, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		h.Unregister(client)
+	}()
+	if _, err := h.Call(context.Background(), "c1", "confirm", nil); !errors.Is(err, hub.ErrCallAborted) {
+		t.Errorf("expected ErrCallAborted, got %v", err)
+	}
+}
@@ -146,6 +146,9 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.MaxConnections != 1000 {
 		t.Errorf("expected 1000, got %d", cfg.MaxConnections)
 	}
+	if cfg.RetryAfter != 5 {
+		t.Errorf("expected 5, got %d", cfg.RetryAfter)
+	}
 	if cfg.PingInterval != 30 {
 		t.Errorf("expected 30, got %d", cfg.PingInterval)
 	}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/hub"
+	"github.com/orchestra-mcp/socket/src/types"
+)
+
+// sessionFrame returns the session control frame written to conn.
+func sessionFrame(t *testing.T, conn *mockConn) types.Message {
+	t.Helper()
+	for _, m := range eventsOn(conn, types.SystemChannel) {
+		if m.Event == types.EventSession {
+			return m
+		}
+	}
+	t.Fatal("no session frame written")
+	return types.Message{}
+}
+
+func TestSessionResumeReplaysMissedMessages(t *testing.T) {
+	h := newTestHub(t)
+	h.SetResume(time.Second, 10)
+
+	_, conn := connectClient(t, h, "resumable")
+	token, _ := sessionFrame(t, conn).Data["token"].(string)
+	if token == "" {
+		t.Fatal("expected a session token")
+	}
+	h.Subscribe("news", "resumable")
+
+	// Drop the connection; the read pump unregisters the client.
+	conn.Close()
+	time.Sleep(30 * time.Millisecond)
+	if h.ClientInfo("resumable") != nil {
+		t.Fatal("client should be unregistered after disconnect")
+	}
+
+	h.Publish("news", types.Message{Channel: "news", Event: "a"})
+	h.Publish("news", types.Message{Channel: "news", Event: "b"})
+	time.Sleep(30 * time.Millisecond)
+
+	id, ok := h.ClaimSession(token, "")
+	if !ok || id != "resumable" {
+		t.Fatalf("claim failed: %q %v", id, ok)
+	}
+	if _, ok := h.ClaimSession(token, ""); ok {
+		t.Error("a token must only be claimable once")
+	}
+
+	_, conn2 := registerClient(t, h, id)
+	frame := sessionFrame(t, conn2)
+	if frame.Data["resumed"] != true || frame.Data["token"] == token {
+		t.Errorf("expected resumed session with a fresh token, got %+v", frame.Data)
+	}
+
+	replayed := eventsOn(conn2, "news")
+	if len(replayed) != 2 || replayed[0].Event != "a" || replayed[1].Event != "b" {
+		t.Fatalf("expected replay of a, b in order, got %+v", replayed)
+	}
+	if h.Channels()["news"] != 1 {
+		t.Error("subscription should be restored")
+	}
+}
+
+func TestSessionNotKeptAfterServerUnregister(t *testing.T) {
+	h := newTestHub(t)
+	h.SetResume(time.Second, 10)
+
+	client, conn := registerClient(t, h, "kicked")
+	token, _ := sessionFrame(t, conn).Data["token"].(string)
+
+	h.Unregister(client)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := h.ClaimSession(token, ""); ok {
+		t.Error("server-removed client must not be resumable")
+	}
+}
+
+func TestSessionExpires(t *testing.T) {
+	h := newTestHub(t)
+	h.SetResume(30*time.Millisecond, 10)
+
+	_, conn := connectClient(t, h, "expiring")
+	token, _ := sessionFrame(t, conn).Data["token"].(string)
+	conn.Close()
+	time.Sleep(80 * time.Millisecond)
+
+	if _, ok := h.ClaimSession(token, ""); ok {
+		t.Error("expired session must not be resumable")
+	}
+}
+
+func TestSessionClaimRequiresSameUser(t *testing.T) {
+	h := newTestHub(t)
+	h.SetResume(time.Second, 10)
+
+	conn := newMockConn()
+	client := hub.NewClient("owned", conn, h)
+	client.SetIdentity(types.Identity{UserID: "u1"})
+	h.Register(client)
+	go client.WritePump()
+	go client.ReadPump()
+	time.Sleep(20 * time.Millisecond)
+
+	token, _ := sessionFrame(t, conn).Data["token"].(string)
+	conn.Close()
+	time.Sleep(30 * time.Millisecond)
+
+	if _, ok := h.ClaimSession(token, "u2"); ok {
+		t.Error("another user must not claim the session")
+	}
+	if _, ok := h.ClaimSession(token, "u1"); !ok {
+		t.Error("the owner should claim the session")
+	}
+}
@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/hub"
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/rs/zerolog"
+)
+
+// newShardedHub creates a hub with n shards and starts it.
+func newShardedHub(t *testing.T, n int) *hub.Hub {
+	t.Helper()
+	h := hub.New(zerolog.Nop(), hub.WithShards(n), hub.WithSendBuffer(1024))
+	go h.Run()
+	t.Cleanup(h.Stop)
+	return h
+}
+
+func TestSlowHandlerDoesNotBlockFanout(t *testing.T) {
+	h := newShardedHub(t, 4)
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	h.RegisterHandler("slow", func(string, types.Message) error {
+		<-release
+		return nil
+	})
+
+	_, subConn := registerClient(t, h, "subscriber")
+	h.Subscribe("news", "subscriber")
+	_, senderConn := connectClient(t, h, "sender")
+	senderConn.readCh <- types.Message{Channel: "slow", Event: "work"}
+	time.Sleep(20 * time.Millisecond)
+
+	for i := range 10 {
+		h.Publish("news", types.Message{Channel: "news", Event: fmt.Sprint(i)})
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(eventsOn(subConn, "news")) < 10 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := len(eventsOn(subConn, "news")); n != 10 {
+		t.Fatalf("broadcasts should not wait for a blocked handler, got %d of 10", n)
+	}
+}
+
+func TestShardedHubKeepsChannelOrder(t *testing.T) {
+	h := newShardedHub(t, 8)
+	channels := []string{"a", "b", "c", "d"}
+	conns := make(map[string]*mockConn)
+	for _, ch := range channels {
+		_, conn := registerClient(t, h, "sub-"+ch)
+		h.Subscribe(ch, "sub-"+ch)
+		conns[ch] = conn
+	}
+
+	const perChannel = 200
+	var wg sync.WaitGroup
+	for _, ch := range channels {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perChannel {
+				h.Publish(ch, types.Message{Channel: ch, Event: fmt.Sprint(i)})
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, ch := range channels {
+		deadline := time.Now().Add(2 * time.Second)
+		for len(eventsOn(conns[ch], ch)) < perChannel && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		got := eventsOn(conns[ch], ch)
+		if len(got) != perChannel {
+			t.Fatalf("channel %s: expected %d messages, got %d", ch, perChannel, len(got))
+		}
+		for i, m := range got {
+			if m.Event != fmt.Sprint(i) {
+				t.Fatalf("channel %s: message %d out of order: %s", ch, i, m.Event)
+			}
+		}
+	}
+}
+
+func TestShardedHubKeepsClientOrder(t *testing.T) {
+	h := newShardedHub(t, 8)
+	var mu sync.Mutex
+	seen := make(map[string][]string)
+	h.RegisterHandler("work", func(clientID string, msg types.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[clientID] = append(seen[clientID], msg.Event)
+		return nil
+	})
+
+	clients := []string{"c1", "c2", "c3", "c4"}
+	const perClient = 100
+	var wg sync.WaitGroup
+	for _, id := range clients {
+		_, conn := connectClient(t, h, id)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perClient {
+				conn.readCh <- types.Message{Channel: "work", Event: fmt.Sprint(i)}
+			}
+		}()
+	}
+	wg.Wait()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		done := true
+		for _, id := range clients {
+			done = done && len(seen[id]) == perClient
+		}
+		mu.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, id := range clients {
+		if len(seen[id]) != perClient {
+			t.Fatalf("client %s: expected %d messages, got %d", id, perClient, len(seen[id]))
+		}
+		for i, ev := range seen[id] {
+			if ev != fmt.Sprint(i) {
+				t.Fatalf("client %s: message %d handled out of order: %s", id, i, ev)
+			}
+		}
+	}
+}
+
+func TestResumeDuringConcurrentBroadcasts(t *testing.T) {
+	h := newShardedHub(t, 4)
+	h.SetResume(time.Second, 1000)
+
+	_, conn := connectClient(t, h, "resumer")
+	token, _ := sessionFrame(t, conn).Data["token"].(string)
+	h.Subscribe("news", "resumer")
+	conn.Close()
+	time.Sleep(30 * time.Millisecond)
+
+	const total = 300
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range total {
+			h.Publish("news", types.Message{Channel: "news", Event: fmt.Sprint(i)})
+			if i%10 == 0 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+	id, ok := h.ClaimSession(token, "")
+	if !ok {
+		t.Fatal("claim failed")
+	}
+	_, conn2 := registerClient(t, h, id)
+	<-done
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(eventsOn(conn2, "news")) < total && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	got := eventsOn(conn2, "news")
+	if len(got) != total {
+		t.Fatalf("expected every message replayed or delivered live, got %d of %d", len(got), total)
+	}
+	for i, m := range got {
+		if m.Event != fmt.Sprint(i) {
+			t.Fatalf("message %d out of order: %s", i, m.Event)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/hub"
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/rs/zerolog"
+)
+
+// newStalledClient registers a client whose WritePump never runs, so its
+// send buffer (capacity 2) fills up.
+func newStalledClient(t *testing.T, id string) (*hub.Hub, *hub.Client) {
+	t.Helper()
+	h := hub.New(zerolog.Nop(), hub.WithSendBuffer(2))
+	go h.Run()
+	t.Cleanup(func() { h.Stop() })
+
+	client := hub.NewClient(id, newMockConn(), h)
+	h.Register(client)
+	time.Sleep(20 * time.Millisecond)
+	return h, client
+}
+
+func numbered(n int) types.Message {
+	return types.Message{Channel: "feed", Event: "tick", Data: map[string]any{"n": n}}
+}
+
+func TestSlowConsumerDropNewest(t *testing.T) {
+	h, client := newStalledClient(t, "slow")
+
+	for i := 0; i < 2; i++ {
+		if err := h.Send("slow", numbered(i)); err != nil {
+			t.Fatalf("send %d: %v", i, err)
+		}
+	}
+	if err := h.Send("slow", numbered(2)); !errors.Is(err, hub.ErrBufferFull) {
+		t.Fatalf("expected ErrBufferFull, got %v", err)
+	}
+	if first := <-client.Send; first.Data["n"] != 0 {
+		t.Errorf("oldest message should be kept, got %v", first.Data["n"])
+	}
+	if info := h.ClientInfo("slow"); info.Dropped != 1 {
+		t.Errorf("expected 1 dropped, got %d", info.Dropped)
+	}
+}
+
+func TestSlowConsumerDropOldest(t *testing.T) {
+	h, client := newStalledClient(t, "slow")
+	h.SetSlowConsumerPolicy(hub.SlowConsumerPolicy{Overflow: hub.OverflowDropOldest})
+
+	for i := 0; i < 3; i++ {
+		if err := h.Send("slow", numbered(i)); err != nil {
+			t.Fatalf("send %d: %v", i, err)
+		}
+	}
+	if first := <-client.Send; first.Data["n"] != 1 {
+		t.Errorf("oldest message should be evicted, got %v", first.Data["n"])
+	}
+	if info := h.ClientInfo("slow"); info.Dropped != 1 {
+		t.Errorf("expected 1 dropped, got %d", info.Dropped)
+	}
+}
+
+func TestSlowConsumerBlockTimesOut(t *testing.T) {
+	h, _ := newStalledClient(t, "slow")
+	h.SetSlowConsumerPolicy(hub.SlowConsumerPolicy{Overflow: hub.OverflowBlock, Timeout: 30 * time.Millisecond})
+
+	_ = h.Send("slow", numbered(0))
+	_ = h.Send("slow", numbered(1))
+
+	start := time.Now()
+	if err := h.Send("slow", numbered(2)); !errors.Is(err, hub.ErrBufferFull) {
+		t.Fatalf("expected ErrBufferFull after timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("send should block for the timeout, returned after %s", elapsed)
+	}
+}
+
+func TestSlowConsumerDisconnectPerChannel(t *testing.T) {
+	h, _ := newStalledClient(t, "slow")
+	h.SetChannelSlowConsumerPolicy("feed", hub.SlowConsumerPolicy{Overflow: hub.OverflowDisconnect})
+
+	reasons := make(chan types.DisconnectReason, 1)
+	h.OnDisconnection(func(_ string, r types.DisconnectReason) { reasons <- r })
+
+	h.Subscribe("feed", "slow")
+	for i := 0; i < 3; i++ {
+		h.Publish("feed", numbered(i))
+	}
+
+	select {
+	case r := <-reasons:
+		if r != types.DisconnectSlowConsumer {
+			t.Errorf("expected slow_consumer reason, got %s", r)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("slow client was not disconnected")
+	}
+	if h.ClientInfo("slow") != nil {
+		t.Error("evicted client should be removed")
+	}
+}
@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orchestra-mcp/socket/src/hub"
+	"github.com/orchestra-mcp/socket/src/types"
+	"github.com/rs/zerolog"
+)
+
+// newPoolHub creates a single-shard hub with the given handler workers.
+func newPoolHub(t *testing.T, workers int, opts ...hub.Option) *hub.Hub {
+	t.Helper()
+	opts = append([]hub.Option{hub.WithShards(1), hub.WithHandlerWorkers(workers)}, opts...)
+	h := hub.New(zerolog.Nop(), opts...)
+	go h.Run()
+	t.Cleanup(h.Stop)
+	return h
+}
+
+// waitUntil polls cond for up to a second.
+func waitUntil(t *testing.T, cond func() bool, what string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBlockedHandlerDoesNotBlockRegistration(t *testing.T) {
+	h := newPoolHub(t, 1)
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	h.RegisterHandler("db", func(string, types.Message) error {
+		<-release
+		return nil
+	})
+
+	_, conn := connectClient(t, h, "c1")
+	conn.readCh <- types.Message{Channel: "db"}
+	waitUntil(t, func() bool { return h.Stats().Handlers.Active == 1 }, "the handler to start")
+
+	registerClient(t, h, "c2")
+	if h.ClientInfo("c2") == nil {
+		t.Fatal("registration should not wait for a running handler")
+	}
+}
+
+func TestHandlersKeepPerClientOrder(t *testing.T) {
+	h := newPoolHub(t, 8)
+	var mu sync.Mutex
+	seen := make(map[string][]int)
+	h.RegisterHandler("seq", func(clientID string, msg types.Message) error {
+		time.Sleep(time.Duration(len(clientID)%3) * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		seen[clientID] = append(seen[clientID], int(msg.Data["n"].(int)))
+		return nil
+	})
+
+	const clients, perClient = 4, 50
+	var wg sync.WaitGroup
+	for i := range clients {
+		_, conn := connectClient(t, h, fmt.Sprintf("client-%d", i))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range perClient {
+				conn.readCh <- types.Message{Channel: "seq", Data: map[string]any{"n": n}}
+			}
+		}()
+	}
+	wg.Wait()
+	waitUntil(t, func() bool { return h.Stats().Handlers.Handled == clients*perClient }, "all messages")
+
+	mu.Lock()
+	defer mu.Unlock()
+	for id, ns := range seen {
+		for i, n := range ns {
+			if n != i {
+				t.Fatalf("%s: message %d handled at position %d", id, n, i)
+			}
+		}
+	}
+}
+
+func TestHandlersRunConcurrentlyAcrossClients(t *testing.T) {
+	h := newPoolHub(t, 2)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var started []string
+	h.RegisterHandler("work", func(clientID string, msg types.Message) error {
+		mu.Lock()
+		started = append(started, clientID+"/"+msg.Event)
+		mu.Unlock()
+		if clientID == "slow" {
+			<-release
+		}
+		return nil
+	})
+
+	_, slow := connectClient(t, h, "slow")
+	_, fast := connectClient(t, h, "fast")
+	slow.readCh <- types.Message{Channel: "work", Event: "1"}
+	slow.readCh <- types.Message{Channel: "work", Event: "2"}
+	waitUntil(t, func() bool { return h.Stats().Handlers.Active == 1 }, "the slow handler")
+	fast.readCh <- types.Message{Channel: "work", Event: "1"}
+	waitUntil(t, func() bool { return h.Stats().Handlers.Handled == 1 }, "the other client's handler")
+
+	mu.Lock()
+	got := fmt.Sprint(started)
+	mu.Unlock()
+	if got != "[slow/1 fast/1]" {
+		t.Errorf("a client's second message must wait for its first, started %s", got)
+	}
+	if q := h.Stats().Handlers.Queued; q != 1 {
+		t.Errorf("expected 1 queued message, got %d", q)
+	}
+
+	close(release)
+	waitUntil(t, func() bool { return h.Stats().Handlers.Handled == 3 }, "the queued message")
+}
+
+func TestHandlerTimeout(t *testing.T) {
+	h := newPoolHub(t, 1, hub.WithHandlerTimeout(20*time.Millisecond))
+	h.RegisterContextHandler("query", func(ctx context.Context, _ string, _ types.Message) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	_, conn := connectClient(t, h, "c1")
+	conn.readCh <- types.Message{Channel: "query"}
+	waitUntil(t, func() bool { return h.Stats().Handlers.TimedOut == 1 }, "the timeout")
+
+	stats := h.Stats().Handlers
+	if stats.Handled != 1 || stats.Failed != 0 || stats.Active != 0 {
+		t.Errorf("unexpected handler stats %+v", stats)
+	}
+}
+
+func TestHandlerContextCancelledOnStop(t *testing.T) {
+	h := hub.New(zerolog.Nop(), hub.WithHandlerWorkers(1))
+	go h.Run()
+	cancelled := make(chan struct{})
+	h.RegisterContextHandler("wait", func(ctx context.Context, _ string, _ types.Message) error {
+		<-ctx.Done()
+		close(cancelled)
+		return nil
+	})
+
+	_, conn := connectClient(t, h, "c1")
+	conn.readCh <- types.Message{Channel: "wait"}
+	waitUntil(t, func() bool { return h.Stats().Handlers.Active == 1 }, "the handler to start")
+	h.Stop()
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("stopping the hub should cancel running handlers")
+	}
+}
+
+func TestHandlerStatsReportPool(t *testing.T) {
+	h := newPoolHub(t, 3, hub.WithHandlerQueue(16))
+	h.RegisterHandler("fail", func(string, types.Message) error {
+		return fmt.Errorf("boom")
+	})
+
+	_, conn := connectClient(t, h, "c1")
+	conn.readCh <- types.Message{Channel: "fail"}
+	waitUntil(t, func() bool { return h.Stats().Handlers.Failed == 1 }, "the failure")
+
+	stats := h.Stats().Handlers
+	if stats.Workers != 3 || stats.QueueSize != 16 || stats.Queued != 0 {
+		t.Errorf("unexpected handler stats %+v", stats)
+	}
+}